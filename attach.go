@@ -0,0 +1,240 @@
+package zet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// attachWarnSize is the size in bytes above which an attachment triggers a
+// warning. GitHub rejects files over 100MB and large binaries bloat the repo.
+const attachWarnSize = 10 << 20
+
+// imageExts are the file extensions which are referenced as Markdown images
+// rather than plain links when attached to a zet.
+var imageExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".svg":  true,
+	".webp": true,
+}
+
+type AttachCmd struct {
+	Id               string   `arg:"" predictor:"ids" help:"Isosec of the zet to attach files to, or 'last'"`
+	Files            []string `arg:"" optional:"" help:"Files to attach. Names not found are looked up in the Pictures, Screenshots and Downloads directories"`
+	LatestScreenshot bool     `help:"Attach the most recent file in the Screenshots directory"`
+	Move             bool     `help:"Move files into the zet instead of copying them"`
+}
+
+func (c *AttachCmd) Run(ctx context.Context) error {
+	// files are resolved first, relative to where zet was run
+	var srcs []string
+	for _, f := range c.Files {
+		src, err := resolveAttachment(f)
+		if err != nil {
+			return err
+		}
+		srcs = append(srcs, src)
+	}
+	if c.LatestScreenshot {
+		s, err := latestFile(Screenshots)
+		if err != nil {
+			return err
+		}
+		srcs = append(srcs, s)
+	}
+	if len(srcs) == 0 {
+		return errors.New("no files to attach")
+	}
+
+	z := new(Zet)
+	zet, err := z.GetZet(c.Id)
+	if err != nil {
+		return err
+	}
+	err = requirePublic(zet, "attached to")
+	if err != nil {
+		return err
+	}
+	names, err := z.Attach(zet, srcs, c.Move)
+	if err != nil {
+		return err
+	}
+	var refs []string
+	for _, name := range names {
+		refs = append(refs, attachmentRef(name))
+		fmt.Printf("Attached %q to %s\n", name, zet)
+		// large files are still attached but may be worth removing again
+		fi, err := os.Stat(filepath.Join(Repo, zet, name))
+		if err == nil && fi.Size() > attachWarnSize {
+			fmt.Fprintf(os.Stderr, term.Yellow+"warning: %q is %d MB, large files bloat the zet repo\n"+term.Reset,
+				name, fi.Size()>>20)
+		}
+	}
+	err = z.AppendReadme(zet, strings.Join(refs, "\n"))
+	if err != nil {
+		return err
+	}
+
+	z.Path = filepath.Join(Repo, zet)
+	z.Op = OpAttach
+	err = z.scanAndCommit(ctx, z.Path)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Attach copies, or moves when move is true, the files at srcs into the zet
+// directory and returns the names they were stored under. Every file is
+// checked before any is attached, and should one still fail those already
+// attached are undone so the zet is left as it was.
+func (z *Zet) Attach(zet string, srcs []string, move bool) ([]string, error) {
+	names := make([]string, len(srcs))
+	seen := make(map[string]bool)
+	for i, src := range srcs {
+		fi, err := os.Stat(src)
+		if err != nil {
+			return nil, err
+		}
+		if fi.IsDir() {
+			return nil, fmt.Errorf("cannot attach directory %q", src)
+		}
+		name := filepath.Base(src)
+		if seen[name] {
+			return nil, fmt.Errorf("more than one file is named %q", name)
+		}
+		seen[name] = true
+		if _, err := os.Stat(filepath.Join(Repo, zet, name)); err == nil {
+			return nil, fmt.Errorf("%q is already attached to %s", name, zet)
+		}
+		names[i] = name
+	}
+
+	for i, src := range srcs {
+		dst := filepath.Join(Repo, zet, names[i])
+		var err error
+		if move {
+			err = moveFile(src, dst)
+		} else {
+			err = copyFile(src, dst)
+		}
+		if err != nil {
+			for j := range i {
+				done := filepath.Join(Repo, zet, names[j])
+				if move {
+					_ = moveFile(done, srcs[j])
+				} else {
+					_ = os.Remove(done)
+				}
+			}
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// AppendReadme appends text to the end of the zet README.md, ensuring it is
+// separated from the existing content by a blank line.
+func (z *Zet) AppendReadme(zet, text string) error {
+	p := z.GetReadme(filepath.Join(Repo, zet))
+	c, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	s := strings.TrimRight(string(c), "\n") + "\n\n" + text + "\n"
+	return os.WriteFile(p, []byte(s), 0664)
+}
+
+// attachmentRef returns the Markdown reference for an attached file. Images
+// are embedded and everything else is linked.
+func attachmentRef(name string) string {
+	u := url.PathEscape(name)
+	if imageExts[strings.ToLower(filepath.Ext(name))] {
+		return fmt.Sprintf("![%s](%s)", name, u)
+	}
+	return fmt.Sprintf("[%s](%s)", name, u)
+}
+
+// resolveAttachment returns the absolute path to an attachment. Paths which
+// exist are used as is, otherwise the utility directories are searched in
+// order.
+func resolveAttachment(name string) (string, error) {
+	if _, err := os.Stat(name); err == nil {
+		return filepath.Abs(name)
+	}
+	for _, dir := range []string{Pictures, Screenshots, Downloads} {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return filepath.Abs(p)
+		}
+	}
+	return "", fmt.Errorf("attachment %q not found", name)
+}
+
+// latestFile returns the most recently modified regular file in dir.
+func latestFile(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var latest string
+	var newest int64
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return "", err
+		}
+		if fi.ModTime().UnixNano() > newest {
+			newest = fi.ModTime().UnixNano()
+			latest = filepath.Join(dir, e.Name())
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no files found in %q", dir)
+	}
+	return latest, nil
+}
+
+// copyFile copies the contents of src to a new file at dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(in)
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0664)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// moveFile renames src to dst, falling back to copy and remove when the two
+// are on different filesystems.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,217 @@
+package zet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type CommitCmd struct {
+	Separate bool `help:"Commit each zet separately rather than all together"`
+}
+
+func (c *CommitCmd) Run(ctx context.Context) error {
+	z := new(Zet)
+	changes, err := z.Changed(ctx)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("No uncommitted zets")
+		return nil
+	}
+	for _, ch := range changes {
+		fmt.Printf("%s %s %s\n", ch.Id, ch.Op, ch.Title)
+	}
+	if term.Prompt("Commit %d zets? (y/N) ", len(changes)) != "y" {
+		return fmt.Errorf("%w, nothing committed", ErrUserDeclinedCommit)
+	}
+	return z.CommitChanges(ctx, changes, c.Separate)
+}
+
+// Change is a zet with uncommitted changes.
+type Change struct {
+	Id    string
+	Op    string
+	Title string
+}
+
+// Changed returns the zets with uncommitted changes according to
+// `git status --porcelain`, ordered by id. Zets not yet committed are reported
+// as created, removed zets as deleted and everything else as edited.
+func (z *Zet) Changed(ctx context.Context) ([]Change, error) {
+	err := z.checkRepo()
+	if err != nil {
+		return nil, err
+	}
+	out, err := gitOut(ctx, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	r := regexp.MustCompile(zetRegex)
+	ids := make(map[string]bool)
+	for _, l := range strings.Split(out, "\n") {
+		if len(l) < 4 {
+			continue
+		}
+		p := l[3:]
+		// a rename reports "old -> new" and both zets have changed
+		if src, dst, ok := strings.Cut(p, " -> "); ok {
+			ids[zetOf(src)] = true
+			p = dst
+		}
+		ids[zetOf(p)] = true
+	}
+
+	var changes []Change
+	for id := range ids {
+		if !r.MatchString(id) {
+			continue
+		}
+		// zets absent from HEAD are new however many of their files changed
+		ch := Change{Id: id, Op: OpEdit}
+		if _, err := os.Stat(filepath.Join(Repo, id)); errors.Is(err, os.ErrNotExist) {
+			ch.Op = OpDelete
+		} else if _, err := gitOut(ctx, "cat-file", "-e", "HEAD:"+id); err != nil {
+			ch.Op = OpCreate
+		}
+		if ch.Op == OpDelete {
+			// the title of a deleted zet is recovered from the last commit
+			if c, err := gitOut(ctx, "show", "HEAD:"+id+"/README.md"); err == nil {
+				ch.Title = parseTitle(c)
+			}
+		} else {
+			c, err := readReadme(filepath.Join(Repo, id))
+			switch {
+			case isLocked(err):
+				ch.Title = lockedTitle
+			case err != nil:
+				return nil, err
+			default:
+				ch.Title = parseTitle(c)
+			}
+		}
+		changes = append(changes, ch)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Id < changes[j].Id })
+	return changes, nil
+}
+
+// zetOf returns the first element of a path reported by git status, which
+// for files within a zet is its id.
+func zetOf(p string) string {
+	id, _, _ := strings.Cut(strings.Trim(p, `"`), "/")
+	return id
+}
+
+// CommitChanges validates changes and commits them after a single pull, either
+// as one commit or one per zet when separate is true, and then pushes them all
+// at once. Deleted zets run the post-delete user hook once pushed.
+func (z *Zet) CommitChanges(ctx context.Context, changes []Change, separate bool) error {
+	for _, ch := range changes {
+		if ch.Op == OpDelete {
+			continue
+		}
+		err := z.checkZet(ctx, filepath.Join(Repo, ch.Id))
+		if err != nil {
+			return err
+		}
+	}
+	err := z.checkPlaintext()
+	if err != nil {
+		return err
+	}
+	err = z.Pull(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to pull from git remote: %w", err)
+	}
+	// every commit is kept for the post-commit hook once pushed
+	var commits []*Zet
+	if separate || len(changes) == 1 {
+		for _, ch := range changes {
+			c := &Zet{Path: filepath.Join(Repo, ch.Id), Op: ch.Op, Id: ch.Id, Title: ch.Title}
+			if ch.Title == "" {
+				c.Title = ch.Id
+			}
+			err = c.runHook(ctx, HookPreCommit)
+			if err != nil {
+				return err
+			}
+			err = c.Add(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to add files to git: %w", err)
+			}
+			err = c.Commit(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to commit files to git: %w", err)
+			}
+			commits = append(commits, c)
+		}
+	} else {
+		var paths, lines []string
+		ops := make(map[string]bool)
+		for _, ch := range changes {
+			paths = append(paths, filepath.Join(Repo, ch.Id))
+			lines = append(lines, fmt.Sprintf("%s %s %s", ch.Id, ch.Op, publicTitle(ch.Id, ch.Title)))
+			z.Ids = append(z.Ids, ch.Id)
+			ops[ch.Op] = true
+		}
+		// the operation is only named when every zet shares it
+		z.Op = OpEdit
+		if len(ops) == 1 {
+			z.Op = changes[0].Op
+		}
+		z.Title = fmt.Sprintf("%d zets\n\n%s", len(changes), strings.Join(lines, "\n"))
+		z.Path = Repo
+		err = z.runHook(ctx, HookPreCommit)
+		if err != nil {
+			return err
+		}
+		err = z.add(ctx, paths...)
+		if err != nil {
+			return fmt.Errorf("failed to add files to git: %w", err)
+		}
+		err = z.Commit(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to commit files to git: %w", err)
+		}
+		commits = append(commits, z)
+	}
+	err = z.Push(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to push files to git: %w", err)
+	}
+	for _, c := range commits {
+		err = c.runHook(ctx, HookPostCommit)
+		if err != nil {
+			return err
+		}
+	}
+	for _, ch := range changes {
+		if ch.Op != OpDelete {
+			continue
+		}
+		d := Zet{Path: filepath.Join(Repo, ch.Id), Op: ch.Op, Id: ch.Id, Title: ch.Title}
+		err = d.runHook(ctx, HookPostDelete)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitUnless commits zet through scanAndCommit unless noCommit is set, in
+// which case the change is left for `zet commit` to pick up later.
+func (z *Zet) commitUnless(ctx context.Context, noCommit bool, zet string) error {
+	if noCommit {
+		fmt.Printf("%s saved, run zet commit to commit it\n", filepath.Base(zet))
+		return nil
+	}
+	return z.scanAndCommit(ctx, zet)
+}
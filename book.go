@@ -0,0 +1,396 @@
+package zet
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	ghtml "github.com/yuin/goldmark/renderer/html"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// bookMediaTypes are the image types which can be embedded in an EPUB.
+var bookMediaTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+type BookCmd struct {
+	Tag    string `help:"Gather zets with this tag" required:"" predictor:"tags" short:"t"`
+	Out    string `help:"File to write the book to" required:"" type:"path" short:"o"`
+	Title  string `help:"Title of the book, defaults to the tag"`
+	Format string `help:"Output format" enum:"epub,md" default:"epub"`
+	Order  string `help:"Order chapters by isosec, or by following links from zets nothing links to" enum:"isosec,links" default:"isosec"`
+}
+
+func (c *BookCmd) Run() error {
+	z := new(Zet)
+	chapters, skipped, err := z.Book(c.Tag, c.Order)
+	if err != nil {
+		return err
+	}
+	title := c.Title
+	if title == "" {
+		title = "#" + c.Tag
+	}
+	if c.Format == "md" {
+		err = writeBookMarkdown(c.Out, title, chapters)
+	} else {
+		err = writeEpub(c.Out, title, chapters)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d chapters to %s\n", len(chapters), c.Out)
+	if skipped > 0 {
+		fmt.Printf("Skipped %d private zets\n", skipped)
+	}
+	return nil
+}
+
+// bookChapter is a zet prepared as a chapter of a book.
+type bookChapter struct {
+	Id    string
+	Title string
+	Body  string
+	Links []string
+}
+
+// Book gathers the zets tagged with tag as chapters ordered by order, either
+// "isosec" or "links". Private zets are never included as that would write
+// their plaintext outside the repo; the number skipped is returned.
+func (z *Zet) Book(tag, order string) ([]bookChapter, int, error) {
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return nil, 0, err
+	}
+	found, err := z.FindTags(tag, files)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(found) == 0 {
+		return nil, 0, fmt.Errorf("%w tagged #%s", ErrNoResults, tag)
+	}
+	var chapters []bookChapter
+	var skipped int
+	for _, t := range found {
+		dir := filepath.Join(Repo, t.Id)
+		if isPrivate(dir) {
+			skipped++
+			continue
+		}
+		c, err := readReadme(dir)
+		if isMissing(t.Id, err) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		_, body := splitFrontMatter(c)
+		ch := bookChapter{Id: t.Id, Title: parseTitle(c), Body: stripTagLines(body) + "\n"}
+		for _, l := range FindLinks(body) {
+			ch.Links = append(ch.Links, l.Id)
+		}
+		chapters = append(chapters, ch)
+	}
+	if order == "links" {
+		chapters = linkOrder(chapters)
+	}
+	return chapters, skipped, nil
+}
+
+// linkOrder orders chapters so that each is followed by the chapters it
+// links to, depth first. Traversal starts from the chapters no other chapter
+// links to, oldest first, and any left unreached, such as those in a cycle,
+// follow in isosec order.
+func linkOrder(chapters []bookChapter) []bookChapter {
+	byId := make(map[string]int, len(chapters))
+	for i, c := range chapters {
+		byId[c.Id] = i
+	}
+	linked := make(map[string]bool)
+	for _, c := range chapters {
+		for _, l := range c.Links {
+			if l != c.Id {
+				linked[l] = true
+			}
+		}
+	}
+	var out []bookChapter
+	seen := make(map[string]bool)
+	var visit func(i int)
+	visit = func(i int) {
+		if seen[chapters[i].Id] {
+			return
+		}
+		seen[chapters[i].Id] = true
+		out = append(out, chapters[i])
+		for _, l := range chapters[i].Links {
+			if j, ok := byId[l]; ok {
+				visit(j)
+			}
+		}
+	}
+	for i, c := range chapters {
+		if !linked[c.Id] {
+			visit(i)
+		}
+	}
+	for i := range chapters {
+		visit(i)
+	}
+	return out
+}
+
+// bookLinks rewrites links between zets in body using target to build the
+// destination of zets within the book. Links to zets outside the book are
+// replaced with their text as they cannot be followed.
+func bookLinks(body string, inBook map[string]bool, target func(id string) string) string {
+	return zetLinkRegex.ReplaceAllStringFunc(body, func(s string) string {
+		m := zetLinkRegex.FindStringSubmatch(s)
+		if !inBook[m[3]] {
+			return m[1]
+		}
+		return "[" + m[1] + "](" + target(m[3]) + ")"
+	})
+}
+
+// bookImages rewrites the images in body which are stored in the zet with id
+// using target to build their new location. It returns the rewritten body and
+// the file names of the images found. Links to other attachments are
+// replaced with their text.
+func bookImages(body, id string, target func(name string) string) (string, []string) {
+	var images []string
+	seen := make(map[string]bool)
+	body = localRefRegex.ReplaceAllStringFunc(body, func(s string) string {
+		m := localRefRegex.FindStringSubmatch(s)
+		name, err := url.PathUnescape(m[2])
+		if err != nil {
+			return s
+		}
+		if _, err := os.Stat(filepath.Join(Repo, id, name)); err != nil {
+			return s
+		}
+		if !strings.HasPrefix(m[1], "!") || bookMediaTypes[strings.ToLower(filepath.Ext(name))] == "" {
+			return strings.TrimSuffix(strings.TrimPrefix(m[1], "["), "]")
+		}
+		if !seen[name] {
+			seen[name] = true
+			images = append(images, name)
+		}
+		return m[1] + "(" + target(name) + ")"
+	})
+	return body, images
+}
+
+// writeBookMarkdown writes chapters to out as a single Markdown file suitable
+// for pandoc. Each chapter heading carries an anchor for cross-links and
+// images are referenced relative to out.
+func writeBookMarkdown(out, title string, chapters []bookChapter) error {
+	inBook := bookIds(chapters)
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\ntitle: %q\n---\n", title)
+	for _, ch := range chapters {
+		body := bookLinks(ch.Body, inBook, func(id string) string { return "#zet-" + id })
+		body, _ = bookImages(body, ch.Id, func(name string) string {
+			p := filepath.Join(Repo, ch.Id, name)
+			if r, err := filepath.Rel(filepath.Dir(out), p); err == nil {
+				p = r
+			}
+			// pandoc accepts paths with spaces when wrapped in <>
+			return "<" + filepath.ToSlash(p) + ">"
+		})
+		fmt.Fprintf(&b, "\n# %s {#zet-%s}\n\n%s", ch.Title, ch.Id, stripTitle(body))
+	}
+	return os.WriteFile(out, []byte(b.String()), 0664)
+}
+
+// writeEpub writes chapters to out as an EPUB 3 book with a navigation
+// document, one XHTML file per chapter and the images they embed.
+func writeEpub(out, title string, chapters []bookChapter) (err error) {
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			_ = os.Remove(out)
+		}
+	}()
+	w := zip.NewWriter(f)
+
+	// the mimetype must come first and be stored uncompressed
+	mt, err := w.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mt, "application/epub+zip"); err != nil {
+		return err
+	}
+	err = writeZip(w, "META-INF/container.xml", epubContainer)
+	if err != nil {
+		return err
+	}
+
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(ghtml.WithXHTML()),
+	)
+	inBook := bookIds(chapters)
+	var manifest, spine, toc strings.Builder
+	for _, ch := range chapters {
+		body := bookLinks(ch.Body, inBook, epubChapter)
+		body, images := bookImages(body, ch.Id, func(name string) string {
+			return "images/" + url.PathEscape(ch.Id+"-"+name)
+		})
+		for i, name := range images {
+			p := "images/" + ch.Id + "-" + name
+			c, err := os.ReadFile(filepath.Join(Repo, ch.Id, name))
+			if err != nil {
+				return err
+			}
+			if err := writeZip(w, "OEBPS/"+p, string(c)); err != nil {
+				return err
+			}
+			fmt.Fprintf(&manifest, "    <item id=\"img-%s-%d\" href=\"%s\" media-type=\"%s\"/>\n",
+				ch.Id, i, html.EscapeString("images/"+url.PathEscape(ch.Id+"-"+name)), bookMediaTypes[strings.ToLower(filepath.Ext(name))])
+		}
+
+		var x bytes.Buffer
+		err := md.Convert([]byte("# "+ch.Title+"\n\n"+stripTitle(body)), &x)
+		if err != nil {
+			return err
+		}
+		err = writeZip(w, "OEBPS/"+epubChapter(ch.Id), fmt.Sprintf(epubPage, html.EscapeString(ch.Title), x.String()))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&manifest, "    <item id=\"ch-%s\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", ch.Id, epubChapter(ch.Id))
+		fmt.Fprintf(&spine, "    <itemref idref=\"ch-%s\"/>\n", ch.Id)
+		fmt.Fprintf(&toc, "      <li><a href=\"%s\">%s</a></li>\n", epubChapter(ch.Id), html.EscapeString(ch.Title))
+	}
+
+	nav := fmt.Sprintf(epubNav, html.EscapeString(title), toc.String())
+	err = writeZip(w, "OEBPS/nav.xhtml", nav)
+	if err != nil {
+		return err
+	}
+	uuid, err := newUUID()
+	if err != nil {
+		return err
+	}
+	opf := fmt.Sprintf(epubPackage, uuid, html.EscapeString(title),
+		time.Now().UTC().Format("2006-01-02T15:04:05Z"), manifest.String(), spine.String())
+	err = writeZip(w, "OEBPS/content.opf", opf)
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// writeZip adds a compressed file holding content to w.
+func writeZip(w *zip.Writer, name, content string) error {
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, content)
+	return err
+}
+
+// epubChapter returns the file name of the chapter for the zet with id.
+func epubChapter(id string) string { return "ch-" + id + ".xhtml" }
+
+// bookIds returns the set of zet ids within chapters.
+func bookIds(chapters []bookChapter) map[string]bool {
+	ids := make(map[string]bool, len(chapters))
+	for _, c := range chapters {
+		ids[c.Id] = true
+	}
+	return ids
+}
+
+// stripTitle removes the first h1 from body as chapters are given their own
+// heading.
+func stripTitle(body string) string {
+	lines := strings.SplitAfter(body, "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(l, "# ") {
+			return strings.TrimLeft(strings.Join(append(lines[:i:i], lines[i+1:]...), ""), "\n")
+		}
+	}
+	return body
+}
+
+// newUUID returns a random version 4 UUID to identify a book.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const epubPackage = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">urn:uuid:%s</dc:identifier>
+    <dc:title>%s</dc:title>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">%s</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+%s  </manifest>
+  <spine>
+%s  </spine>
+</package>
+`
+
+const epubNav = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>%[1]s</title></head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>%[1]s</h1>
+    <ol>
+%[2]s    </ol>
+  </nav>
+</body>
+</html>
+`
+
+const epubPage = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>%s</title></head>
+<body>
+%s</body>
+</html>
+`
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kong"
+)
+
+// completeCmd is the hidden command the completion scripts call with the
+// words typed so far, the last being the one completed. Candidates are
+// printed one per line as the value and a description separated by a tab.
+const completeCmd = "__complete"
+
+type CompletionCmd struct {
+	Shell string `arg:"" help:"Shell to print the completion script for" enum:"bash,zsh,fish"`
+}
+
+func (c *CompletionCmd) Run() error {
+	fmt.Print(completionScripts[c.Shell])
+	return nil
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for zet, load with: source <(zet completion bash)
+_zet() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" IFS=$'\n'
+	local out
+	out=$(zet ` + completeCmd + ` "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur" 2>/dev/null) || return
+	COMPREPLY=($(compgen -W "$(printf '%s\n' "$out" | cut -f1)" -- "$cur"))
+}
+complete -o default -F _zet zet
+`,
+	"zsh": `#compdef zet
+# zsh completion for zet, load with: source <(zet completion zsh)
+_zet() {
+	local -a lines candidates
+	local l
+	lines=("${(@f)$(zet ` + completeCmd + ` "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}")
+	for l in $lines; do
+		[[ -z $l ]] && continue
+		candidates+=("${${l%%$'\t'*}//:/\\:}:${l#*$'\t'}")
+	done
+	if (( ${#candidates} )); then
+		_describe zet candidates
+	else
+		_files
+	fi
+}
+compdef _zet zet
+`,
+	"fish": `# fish completion for zet, load with: zet completion fish | source
+function __zet_complete
+	set -l tokens (commandline -opc)
+	set -e tokens[1]
+	zet ` + completeCmd + ` $tokens (commandline -ct) 2>/dev/null
+end
+complete -c zet -f -a '(__zet_complete)'
+`,
+}
+
+// candidate is a single completion and its description.
+type candidate struct {
+	Value string
+	Help  string
+}
+
+// complete returns the candidates for the last of args given the words
+// before it, walking the kong model to find the command, flag or positional
+// argument being completed.
+func complete(ctx context.Context, parser *kong.Kong, args []string) []candidate {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	cur := args[len(args)-1]
+	node := parser.Model.Node
+	pos := 0
+	var pending *kong.Flag
+	for _, a := range args[:len(args)-1] {
+		switch {
+		case pending != nil:
+			pending = nil
+		case a == "--":
+		case strings.HasPrefix(a, "-"):
+			if f, ok := flagsOf(node)[a]; ok && !f.IsBool() {
+				pending = f
+			}
+		default:
+			if child := childOf(node, a); child != nil {
+				node, pos = child, 0
+			} else {
+				pos++
+			}
+		}
+	}
+
+	var all []candidate
+	switch {
+	case pending != nil:
+		all = predict(ctx, pending.Value)
+	case strings.HasPrefix(cur, "-"):
+		seen := make(map[*kong.Flag]bool)
+		for n := node; n != nil; n = n.Parent {
+			for _, f := range n.Flags {
+				if f.Hidden || seen[f] {
+					continue
+				}
+				seen[f] = true
+				all = append(all, candidate{"--" + f.Name, f.Help})
+			}
+		}
+	case len(node.Children) > 0:
+		for _, c := range node.Children {
+			if !c.Hidden {
+				all = append(all, candidate{c.Name, c.Help})
+			}
+		}
+		if node == parser.Model.Node {
+			for _, p := range zet.Plugins() {
+				if childOf(node, p.Name) == nil {
+					all = append(all, candidate{p.Name, "plugin " + p.Path})
+				}
+			}
+		}
+	case len(node.Positional) > 0:
+		if pos >= len(node.Positional) {
+			last := node.Positional[len(node.Positional)-1]
+			if !last.IsSlice() {
+				return nil
+			}
+			pos = len(node.Positional) - 1
+		}
+		all = predict(ctx, node.Positional[pos])
+	}
+
+	var out []candidate
+	for _, c := range all {
+		if strings.HasPrefix(c.Value, cur) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// predict returns the values for v, from its enum or its predictor tag: ids
+// completes zet ids described by their titles and tags the tags in use.
+func predict(ctx context.Context, v *kong.Value) []candidate {
+	var out []candidate
+	if v.Enum != "" {
+		for _, e := range v.EnumSlice() {
+			out = append(out, candidate{Value: e})
+		}
+		return out
+	}
+	z := new(zet.Zet)
+	switch v.Tag.Get("predictor") {
+	case "ids":
+		files, err := z.ReadDir(zet.Repo)
+		if err != nil {
+			return nil
+		}
+		titles, err := z.FindTitlesContext(ctx, files)
+		if err != nil {
+			return nil
+		}
+		// newest first as recent zets are the most likely wanted
+		for _, t := range slices.Backward(titles) {
+			out = append(out, candidate{t.Id, t.Title})
+		}
+	case "tags":
+		tags, err := z.AllTags(ctx)
+		if err != nil {
+			return nil
+		}
+		for _, t := range tags {
+			out = append(out, candidate{Value: t})
+		}
+	}
+	return out
+}
+
+// flagsOf returns the flags accepted by node, including those of its parents,
+// keyed by their long and short forms.
+func flagsOf(node *kong.Node) map[string]*kong.Flag {
+	flags := make(map[string]*kong.Flag)
+	for n := node; n != nil; n = n.Parent {
+		for _, f := range n.Flags {
+			flags["--"+f.Name] = f
+			if f.Short != 0 {
+				flags["-"+string(f.Short)] = f
+			}
+		}
+	}
+	return flags
+}
+
+// childOf returns the command of node called name or one of its aliases.
+func childOf(node *kong.Node, name string) *kong.Node {
+	for _, c := range node.Children {
+		if c.Name == name || slices.Contains(c.Aliases, name) {
+			return c
+		}
+	}
+	return nil
+}
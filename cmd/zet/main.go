@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/danielmichaels/zet-cmd"
 	"github.com/danielmichaels/zet-cmd/internal/version"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/alecthomas/kong"
 )
@@ -26,30 +31,41 @@ type CLI struct {
 	zet.Globals
 
 	// Commands
-	Create zet.CreateCmd `cmd:"" aliases:"new" help:"Create a new zet"`
-	Last   zet.LastCmd   `cmd:"" help:"Show the last created zet's isosec (location)'"`
-	Edit   zet.EditCmd   `cmd:"" help:"Edit a zet"`
-	Find   zet.FindCmd   `cmd:"" help:"Search for a zet title and retrieve any matching entry"`
-	Check  zet.CheckCmd  `cmd:"" help:"Check zettelkasten for issues"`
-	Tags   zet.TagsCmd   `cmd:"" help:"Search for a zet by tag and retrieve any entries with that tag"`
-	Git    zet.GitCmd    `cmd:"" help:"Git operations for zettelkasten"`
-	View   zet.ViewCmd   `cmd:"" help:"View supports both direct 'isosec' lookup's and keyword searches"`
+	Create  zet.CreateCmd  `cmd:"" aliases:"new" help:"Create a new zet"`
+	Last    zet.LastCmd    `cmd:"" help:"Show the last created zet's isosec (location)'"`
+	Edit    zet.EditCmd    `cmd:"" help:"Edit a zet"`
+	Find    zet.FindCmd    `cmd:"" help:"Search for a zet title and retrieve any matching entry"`
+	Check   zet.CheckCmd   `cmd:"" help:"Check zettelkasten for issues"`
+	Tags    zet.TagsCmd    `cmd:"" help:"Search for a zet by tag and retrieve any entries with that tag"`
+	Git     zet.GitCmd     `cmd:"" help:"Git operations for zettelkasten"`
+	View    zet.ViewCmd    `cmd:"" help:"View supports both direct 'isosec' lookup's and keyword searches"`
+	Attach  zet.AttachCmd  `cmd:"" help:"Attach files or the latest screenshot to a zet"`
+	Retitle zet.RetitleCmd `cmd:"" help:"Change the title of a zet and update links to it"`
+	Split   zet.SplitCmd   `cmd:"" help:"Split sections of a zet, marked in the editor, out into new zets"`
+	Merge   zet.MergeCmd   `cmd:"" help:"Merge one zet into another leaving a redirect stub"`
+	Random  zet.RandomCmd  `cmd:"" help:"View a random zet to resurface a forgotten note"`
+	Review  zet.ReviewCmd  `cmd:"" help:"Run a spaced repetition review session"`
+	Today   zet.TodayCmd   `cmd:"" help:"Open or append to today's journal"`
+	Journal zet.JournalCmd `cmd:"" help:"List journals or render the past week"`
+	Stats   zet.StatsCmd   `cmd:"" help:"Report statistics and activity for the zettelkasten"`
+	Export  zet.ExportCmd  `cmd:"" help:"Export zets to other note apps"`
+	Import  zet.ImportCmd  `cmd:"" help:"Import a folder of Markdown files as zets"`
+	Book    zet.BookCmd    `cmd:"" help:"Compile the zets with a tag into an EPUB or Markdown book"`
+	Commit  zet.CommitCmd  `cmd:"" help:"Commit every zet changed with --no-commit and push once"`
+	Hooks   zet.HooksCmd   `cmd:"" help:"Manage the git hooks of the zettelkasten"`
+	Plugins zet.PluginsCmd `cmd:"" help:"Manage zet-* plugins, run as zet <name>"`
+
+	Completion CompletionCmd `cmd:"" help:"Print the shell completion script for bash, zsh or fish"`
 }
 
-func run() error {
+// newParser returns the kong parser for cli with commands bound to ctx.
+func newParser(ctx context.Context, cli *CLI, options ...kong.Option) (*kong.Kong, error) {
 	ver := version.Get()
 	if ver == "unavailable" {
 		ver = "development"
 	}
-	cli := CLI{
-		Version: VersionFlag(ver),
-	}
-	// Display help if no args are provided instead of an error message
-	if len(os.Args) < 2 {
-		os.Args = append(os.Args, "--help")
-	}
-
-	ctx := kong.Parse(&cli,
+	cli.Version = VersionFlag(ver)
+	return kong.New(cli, append([]kong.Option{
 		kong.Name(appName),
 		kong.Description(fmt.Sprintf("%s is a zettelkasten tool", appName)),
 		kong.UsageOnError(),
@@ -59,13 +75,129 @@ func run() error {
 		kong.DefaultEnvars(appName),
 		kong.Vars{
 			"version": string(cli.Version),
-		})
-	err := ctx.Run(cli.Globals)
-	ctx.FatalIfErrorf(err)
-	return nil
+		},
+		kong.BindTo(ctx, (*context.Context)(nil)),
+	}, options...)...)
 }
+
+// run parses args and runs the selected command, or the plugin named when it
+// is not a built-in command. It is separate from main so that commands can be
+// driven end to end from tests.
+func run(parser *kong.Kong, cli *CLI, args []string) error {
+	if len(args) > 0 && args[0] == completeCmd {
+		for _, c := range complete(context.Background(), parser, args[1:]) {
+			fmt.Printf("%s\t%s\n", c.Value, c.Help)
+		}
+		return nil
+	}
+	if i, path := findPlugin(parser, args); path != "" {
+		// only the global flags may come before the plugin name
+		var g zet.Globals
+		gp, err := kong.New(&g, kong.Name(appName), kong.DefaultEnvars(appName))
+		if err != nil {
+			return err
+		}
+		_, err = gp.Parse(args[:i])
+		if err != nil {
+			return err
+		}
+		zet.SetupLogging(os.Stderr, g.Verbose, g.LogFormat)
+		slog.Debug("plugin", "path", path)
+		return zet.RunPlugin(path, args[i+1:], g)
+	}
+	ctx, err := parser.Parse(args)
+	if err != nil {
+		return err
+	}
+	zet.SetupLogging(os.Stderr, cli.Verbose, cli.LogFormat)
+	slog.Debug("command", "name", ctx.Command(), "repo", zet.Repo)
+	zet.GitTimeout = cli.GitTimeout
+	zet.NoPager = cli.NoPager
+	zet.Style, zet.Width, zet.Raw = cli.Style, cli.Width, cli.Raw
+	zet.CommitTemplate, zet.ConventionalCommits = cli.CommitTemplate, cli.ConventionalCommits
+	return ctx.Run(cli.Globals)
+}
+
+// findPlugin returns the index in args of the command and the path of its
+// plugin when the command is not built in and a plugin for it is on PATH.
+func findPlugin(parser *kong.Kong, args []string) (int, string) {
+	flags := flagsOf(parser.Model.Node)
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			return -1, ""
+		}
+		if strings.HasPrefix(a, "-") {
+			// skip the value of flags given as --flag value
+			if f, ok := flags[a]; ok && !f.IsBool() {
+				i++
+			}
+			continue
+		}
+		if childOf(parser.Model.Node, a) != nil {
+			return -1, ""
+		}
+		path, err := zet.LookPlugin(a)
+		if err != nil {
+			return -1, ""
+		}
+		return i, path
+	}
+	return -1, ""
+}
+
+// exitCodes gives the outcomes scripts may want to handle their own exit
+// codes, as documented in the README. Other errors exit with 1 and usage
+// errors with 80.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{zet.ErrNoResults, 3},
+	{zet.ErrCancelled, 4},
+	{zet.ErrInvalidSelection, 5},
+	{zet.ErrUserDeclinedCommit, 6},
+}
+
+// exitCode returns the exit code for err when it is one of exitCodes.
+func exitCode(err error) (int, bool) {
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.code, true
+		}
+	}
+	return 0, false
+}
+
 func main() {
-	if err := run(); err != nil {
-		os.Exit(1)
+	// Ctrl-C cancels the context rather than killing zet outright so running
+	// git commands are interrupted and given the chance to clean up. Only the
+	// first is caught, a second kills zet as usual, such as at a prompt.
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	context.AfterFunc(sigCtx, stop)
+
+	var cli CLI
+	parser, err := newParser(sigCtx, &cli)
+	if err != nil {
+		panic(err)
+	}
+	args := os.Args[1:]
+	// Display help if no args are provided instead of an error message
+	if len(args) == 0 {
+		args = []string{"--help"}
+	}
+	err = run(parser, &cli, args)
+	// plugins report their own errors, only their exit code is passed on
+	var pe *zet.PluginExit
+	if errors.As(err, &pe) {
+		os.Exit(pe.Code)
+	}
+	// these are not failures so are printed as "zet: <err>" without the
+	// "error:" label FatalIfErrorf adds, and exit with their own code
+	if code, ok := exitCode(err); ok {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", appName, err)
+		os.Exit(code)
 	}
+	parser.FatalIfErrorf(err)
 }
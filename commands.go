@@ -1,24 +1,48 @@
 package zet
 
 import (
+	"context"
 	"fmt"
-	"github.com/charmbracelet/glamour"
 	"github.com/danielmichaels/zet-cmd/internal/term"
-	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
+	"time"
 )
 
 type Globals struct {
-	Verbose bool `help:"Enable verbose mode" short:"v"`
+	Verbose    bool          `help:"Enable verbose mode" short:"v"`
+	LogFormat  string        `help:"Format of verbose logs" enum:"text,json" default:"text"`
+	GitTimeout time.Duration `help:"Timeout for each git operation when committing, 0 to disable" default:"2m"`
+	NoPager    bool          `help:"Print long output directly rather than through $PAGER"`
+	Style      string        `help:"Rendering style: auto, dark, light, notty or the path to a JSON stylesheet" default:"auto"`
+	Width      int           `help:"Column to wrap rendered zets at, 0 for the terminal width"`
+	Raw        bool          `help:"Print the Markdown source of zets instead of rendering it"`
+
+	CommitTemplate      string `help:"Go template for commit messages, see the README for the fields available"`
+	ConventionalCommits bool   `help:"Prefix commit subjects with a Conventional Commits type, e.g. feat(create): Title"`
 }
 
 type CreateCmd struct {
-	Title string `arg:"" help:"Title of the zet to create"`
+	Title    string `arg:"" help:"Title of the zet to create"`
+	Private  bool   `help:"Encrypt the zet so its content is never committed in plaintext"`
+	NoCommit bool   `help:"Save the zet without committing, see zet commit"`
 }
 
-func (c *CreateCmd) Run() error {
-	z := Zet{Title: c.Title}
+func (c *CreateCmd) Run(ctx context.Context) error {
+	z := Zet{Title: c.Title, Op: OpCreate}
+	if c.Private {
+		err := z.createPrivate()
+		if err != nil {
+			return err
+		}
+		z.Id = filepath.Base(z.Path)
+		err = z.runHook(ctx, HookPostCreate)
+		if err != nil {
+			return err
+		}
+		return z.commitUnless(ctx, c.NoCommit, z.Path)
+	}
 
 	dir, err := z.CreateDir()
 	if err != nil {
@@ -37,7 +61,12 @@ func (c *CreateCmd) Run() error {
 	if err != nil {
 		return err
 	}
-	err = z.scanAndCommit(z.Path)
+	z.Id = filepath.Base(z.Path)
+	err = z.runHook(ctx, HookPostCreate)
+	if err != nil {
+		return err
+	}
+	err = z.commitUnless(ctx, c.NoCommit, z.Path)
 	if err != nil {
 		return err
 	}
@@ -49,7 +78,7 @@ type LastCmd struct{}
 
 func (c *LastCmd) Run() error {
 	z := new(Zet)
-	err := z.ChangeDir(z.GetRepo())
+	err := z.checkRepo()
 	if err != nil {
 		return err
 	}
@@ -69,10 +98,11 @@ type EditCmd struct {
 }
 
 type EditSearchCmd struct {
-	Search string `arg:"" help:"Search for a zet note"`
+	Search   string `arg:"" predictor:"ids" help:"Search for a zet note"`
+	NoCommit bool   `help:"Save the zet without committing, see zet commit"`
 }
 
-func (c *EditSearchCmd) Run() error {
+func (c *EditSearchCmd) Run(ctx context.Context) error {
 	z := new(Zet)
 	r := regexp.MustCompile(zetRegex)
 
@@ -81,44 +111,48 @@ func (c *EditSearchCmd) Run() error {
 		if err != nil {
 			return err
 		}
-		err = z.openZetForEdit(zet)
+		z.Path = filepath.Join(Repo, zet)
+		err = z.openZetForEdit(z.Path)
 		if err != nil {
 			return err
 		}
 
-		err = z.scanAndCommit(zet)
+		err = z.commitUnless(ctx, c.NoCommit, z.Path)
 		if err != nil {
 			return err
 		}
 		return nil
 	}
-	err := z.edit(c.Search)
+	err := z.edit(ctx, c.Search, c.NoCommit)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-type EditLastCmd struct{}
+type EditLastCmd struct {
+	NoCommit bool `help:"Save the zet without committing, see zet commit"`
+}
 
-func (c *EditLastCmd) Run() error {
+func (c *EditLastCmd) Run(ctx context.Context) error {
 	z := new(Zet)
-	err := z.ChangeDir(z.GetRepo())
+	err := z.checkRepo()
 	if err != nil {
 		return err
 	}
 
-	last, err := z.Last()
+	// Last sets z.Path to the zet found
+	_, err = z.Last()
 	if err != nil {
 		return err
 	}
 
-	err = z.openZetForEdit(last)
+	err = z.openZetForEdit(z.Path)
 	if err != nil {
 		return err
 	}
 
-	err = z.scanAndCommit(last)
+	err = z.commitUnless(ctx, c.NoCommit, z.Path)
 	if err != nil {
 		return err
 	}
@@ -133,12 +167,11 @@ type FindCmd struct {
 func (c *FindCmd) Run() error {
 	z := new(Zet)
 
-	err := z.ChangeDir(z.GetRepo())
+	err := z.checkRepo()
 	if err != nil {
 		return err
 	}
-	dir, _ := os.Getwd()
-	files, err := z.ReadDir(dir)
+	files, err := z.ReadDir(Repo)
 	if err != nil {
 		return err
 	}
@@ -157,17 +190,16 @@ func (c *FindCmd) Run() error {
 }
 
 type TagsCmd struct {
-	Query string `arg:"" help:"Query to search"`
+	Query string `arg:"" predictor:"tags" help:"Query to search"`
 }
 
 func (c *TagsCmd) Run() error {
 	z := new(Zet)
-	err := z.ChangeDir(z.GetRepo())
+	err := z.checkRepo()
 	if err != nil {
 		return err
 	}
-	dir, _ := os.Getwd()
-	files, err := z.ReadDir(dir)
+	files, err := z.ReadDir(Repo)
 	if err != nil {
 		return err
 	}
@@ -188,7 +220,7 @@ type ViewCmd struct {
 }
 
 type ViewSearchCmd struct {
-	Search string `arg:"" help:"View a zet by searching for strings or isosec e.g. 20220424000235"`
+	Search string `arg:"" predictor:"ids" help:"View a zet by searching for strings or isosec e.g. 20220424000235"`
 }
 
 func (c *ViewSearchCmd) Run() error {
@@ -200,23 +232,15 @@ func (c *ViewSearchCmd) Run() error {
 		if err != nil {
 			return err
 		}
-		file := filepath.Join(Repo, zet, "README.md")
-		r, err := glamour.NewTermRenderer(
-			glamour.WithAutoStyle(), glamour.WithWordWrap(zetWordWrap),
-		)
+		c, err := readZet(filepath.Join(Repo, zet))
 		if err != nil {
 			return err
 		}
-		c, err := os.ReadFile(file)
+		out, err := renderMarkdown(c)
 		if err != nil {
 			return err
 		}
-		out, err := r.Render(string(c))
-		if err != nil {
-			return err
-		}
-		fmt.Print(out)
-		return nil
+		return page(out)
 	}
 	err := z.render(c.Search)
 	if err != nil {
@@ -229,12 +253,11 @@ type ViewAllCmd struct{}
 
 func (c *ViewAllCmd) Run() error {
 	z := new(Zet)
-	err := z.ChangeDir(z.GetRepo())
+	err := z.checkRepo()
 	if err != nil {
 		return err
 	}
-	dir, _ := os.Getwd()
-	files, err := z.ReadDir(dir)
+	files, err := z.ReadDir(Repo)
 	if err != nil {
 		return err
 	}
@@ -242,17 +265,18 @@ func (c *ViewAllCmd) Run() error {
 	if err != nil {
 		return err
 	}
+	var b strings.Builder
 	for _, v := range titles {
-		fmt.Println(v.Id, v.Title)
+		fmt.Fprintln(&b, v.Id, v.Title)
 	}
-	return nil
+	return page(b.String())
 }
 
 type CheckCmd struct{}
 
-func (c *CheckCmd) Run() error {
+func (c *CheckCmd) Run(ctx context.Context) error {
 	z := new(Zet)
-	err := z.CheckZetConfig()
+	err := z.CheckZetConfig(ctx)
 	if err != nil {
 		return err
 	}
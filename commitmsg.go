@@ -0,0 +1,170 @@
+package zet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Operations recorded in commit messages, set on Zet.Op by each command.
+const (
+	OpCreate  = "create"
+	OpEdit    = "edit"
+	OpDelete  = "delete"
+	OpRetitle = "retitle"
+	OpSplit   = "split"
+	OpMerge   = "merge"
+	OpAttach  = "attach"
+	OpJournal = "journal"
+	OpImport  = "import"
+	OpReview  = "review"
+)
+
+// conventionalTypes maps each operation to its Conventional Commits type.
+var conventionalTypes = map[string]string{
+	OpCreate:  "feat",
+	OpEdit:    "docs",
+	OpDelete:  "revert",
+	OpRetitle: "refactor",
+	OpSplit:   "refactor",
+	OpMerge:   "refactor",
+	OpAttach:  "docs",
+	OpJournal: "docs",
+	OpImport:  "feat",
+	OpReview:  "chore",
+}
+
+// defaultCommitTemplate gives subjects such as "Edit: Title" and keeps any
+// further detail, like the histories recorded by merge, as the body.
+const defaultCommitTemplate = `{{.Prefix}}{{.Title}}{{with .Body}}
+
+{{.}}{{end}}`
+
+// commitTrailer is the git trailer carrying the zet id, so the history of a
+// zet can be found with `git log --grep "Zet-Id: <id>"`.
+const commitTrailer = "Zet-Id"
+
+var (
+	// CommitTemplate is a text/template for commit messages, see CommitData
+	// for the fields available. Empty uses defaultCommitTemplate.
+	CommitTemplate string
+	// ConventionalCommits prefixes subjects with a Conventional Commits type
+	// and the operation as scope, e.g. "feat(create): Title".
+	ConventionalCommits bool
+)
+
+// shortstatRegex matches the counts in `git diff --shortstat` output.
+var shortstatRegex = regexp.MustCompile(`(\d+) (file|insertion|deletion)`)
+
+// CommitData is passed to CommitTemplate when committing.
+type CommitData struct {
+	// Op is the operation such as "create" or "edit".
+	Op string
+	// Id is the isosec of the zet committed, empty when several are.
+	Id string
+	// Ids are the isosecs of every zet committed.
+	Ids []string
+	// Title is the zet title or a summary of the operation, "private zet"
+	// for private zets.
+	Title string
+	// Body is any detail beyond the title, such as merged histories.
+	Body string
+	// Tags are the tags of the zet, without the leading '#'.
+	Tags []string
+	// Prefix is "Op: ", or the Conventional Commits type and scope when
+	// enabled, ready to go before the title.
+	Prefix string
+	// Files, Insertions and Deletions are the staged diff stats.
+	Files      int
+	Insertions int
+	Deletions  int
+}
+
+// commitMessage renders the commit message for the staged changes using
+// CommitTemplate. The id of each zet committed is appended as a trailer.
+func (z *Zet) commitMessage(ctx context.Context) (string, error) {
+	d := CommitData{Op: z.Op, Id: z.Id, Ids: z.Ids}
+	if len(d.Ids) == 0 && d.Id != "" {
+		d.Ids = []string{d.Id}
+	}
+	if d.Op == "" {
+		d.Op = OpEdit
+	}
+	d.Title, d.Body, _ = strings.Cut(z.Title, "\n\n")
+	d.Body = strings.TrimSpace(d.Body)
+	private := d.Id != "" && isPrivate(filepath.Join(Repo, d.Id))
+	if private {
+		// neither the title nor the tags of a private zet are committed
+		d.Title, d.Body = privateTitle, ""
+	}
+	d.Prefix = capitalize(d.Op) + ": "
+	if ConventionalCommits {
+		t := conventionalTypes[d.Op]
+		if t == "" {
+			t = "chore"
+		}
+		d.Prefix = fmt.Sprintf("%s(%s): ", t, d.Op)
+	}
+	if d.Id != "" && !private {
+		if c, err := readReadme(filepath.Join(Repo, d.Id)); err == nil {
+			d.Tags = parseTags(c)
+		}
+	}
+	stat, err := gitOut(ctx, "diff", "--cached", "--shortstat")
+	if err != nil {
+		return "", err
+	}
+	for _, m := range shortstatRegex.FindAllStringSubmatch(stat, -1) {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "file":
+			d.Files = n
+		case "insertion":
+			d.Insertions = n
+		case "deletion":
+			d.Deletions = n
+		}
+	}
+
+	text := CommitTemplate
+	if text == "" {
+		text = defaultCommitTemplate
+	}
+	tmpl, err := template.New("commit").Funcs(template.FuncMap{
+		"join":       strings.Join,
+		"capitalize": capitalize,
+	}).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing commit template: %w", err)
+	}
+	var b bytes.Buffer
+	err = tmpl.Execute(&b, d)
+	if err != nil {
+		return "", fmt.Errorf("executing commit template: %w", err)
+	}
+	msg := strings.TrimSpace(b.String())
+	if msg == "" {
+		return "", fmt.Errorf("commit template produced an empty message")
+	}
+	if len(d.Ids) > 0 {
+		msg += "\n"
+		for _, id := range d.Ids {
+			msg += "\n" + commitTrailer + ": " + id
+		}
+	}
+	return msg, nil
+}
+
+// capitalize returns s with its first letter in upper case.
+func capitalize(s string) string {
+	for i, r := range s {
+		return string(unicode.ToUpper(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
@@ -1,9 +1,9 @@
 package zet
 
 import (
+	"context"
 	"fmt"
 	"github.com/danielmichaels/zet-cmd/internal/term"
-	"os"
 	"path/filepath"
 	"strconv"
 )
@@ -16,7 +16,11 @@ type Found struct {
 }
 
 // openZetForEdit opens the README.md file of a specified zet note for editing using the configured editor.
+// Private zets are decrypted into a temporary file for editing and encrypted again afterwards.
 func (z *Zet) openZetForEdit(zet string) error {
+	if isPrivate(zet) {
+		return editPrivate(zet)
+	}
 	file := filepath.Join(zet, "README.md")
 	err := term.Exec(Editor, file)
 	if err != nil {
@@ -24,8 +28,8 @@ func (z *Zet) openZetForEdit(zet string) error {
 	}
 	return nil
 }
-func (z *Zet) edit(args ...string) error {
-	err := z.searchScanner(args[0])
+func (z *Zet) edit(ctx context.Context, search string, noCommit bool) error {
+	err := z.searchScanner(search)
 	if err != nil {
 		return err
 	}
@@ -33,7 +37,7 @@ func (z *Zet) edit(args ...string) error {
 	if err != nil {
 		return err
 	}
-	err = z.scanAndCommit(z.Path)
+	err = z.commitUnless(ctx, noCommit, z.Path)
 	if err != nil {
 		return err
 	}
@@ -42,14 +46,13 @@ func (z *Zet) edit(args ...string) error {
 
 // searchScanner searches for zet notes matching the provided search term, displays matching results,
 // and prompts the user to select a specific zet note. It updates the Zet struct with the selected
-// note's path and title. If no matching notes are found or an invalid selection is made, the program exits.
+// note's path and title. ErrNoResults, ErrCancelled or ErrInvalidSelection is returned when no zet is chosen.
 func (z *Zet) searchScanner(args ...string) error {
-	err := z.ChangeDir(Repo)
+	err := z.checkRepo()
 	if err != nil {
 		return err
 	}
-	dir, _ := os.Getwd()
-	files, err := z.ReadDir(dir)
+	files, err := z.ReadDir(Repo)
 	if err != nil {
 		return err
 	}
@@ -70,30 +73,30 @@ func (z *Zet) searchScanner(args ...string) error {
 		ff = append(ff, f)
 	}
 	if len(ff) == 0 {
-		fmt.Printf("No entries found for %q\n", args[0])
-		os.Exit(0)
+		return fmt.Errorf("%w for %q", ErrNoResults, args[0])
 	}
 	for _, k := range ff {
 		fmt.Printf("%d) %s %s\n", k.Index, k.Id, k.Title)
 	}
 	prompt := term.Prompt("#> ")
 	if prompt == "" {
-		fmt.Println("exiting. did not provide valid entry.")
-		os.Exit(0)
+		return fmt.Errorf("%w, no zet chosen", ErrCancelled)
 	}
 
-	s, _ := strconv.Atoi(prompt)
+	s, err := strconv.Atoi(prompt)
+	if err != nil {
+		return fmt.Errorf("%w: %q is not a number", ErrInvalidSelection, prompt)
+	}
 	var zet string
 	for _, k := range ff {
 		if s == k.Index {
 			zet = k.Id
-			z.Path = k.Id
+			z.Path = filepath.Join(Repo, k.Id)
 			z.Title = k.Title
 		}
 	}
 	if zet == "" {
-		fmt.Println("Key entered does not match, or zet could not be found")
-		os.Exit(0)
+		return fmt.Errorf("%w: %q does not match a zet listed", ErrInvalidSelection, prompt)
 	}
 	return nil
 }
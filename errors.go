@@ -0,0 +1,19 @@
+package zet
+
+import "errors"
+
+// Errors returned when a command stops short without failing, so that callers
+// can tell the outcomes apart. cmd/zet maps each to its own exit code.
+var (
+	// ErrCancelled is returned when a prompt is left unanswered, such as when
+	// choosing a zet from search results.
+	ErrCancelled = errors.New("cancelled")
+	// ErrNoResults is returned when a search matches no zets.
+	ErrNoResults = errors.New("no zets found")
+	// ErrInvalidSelection is returned when the answer to a prompt does not
+	// match any of the choices offered.
+	ErrInvalidSelection = errors.New("invalid selection")
+	// ErrUserDeclinedCommit is returned when the user answers no when asked
+	// to commit. Any changes are left in place uncommitted.
+	ErrUserDeclinedCommit = errors.New("commit declined")
+)
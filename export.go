@@ -0,0 +1,293 @@
+package zet
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// exportAssets is the folder attachments are copied into in exported vaults.
+const exportAssets = "assets"
+
+// unsafeNameRegex matches characters which are not allowed in file names on
+// common platforms or which break [[links]] in Obsidian and Logseq.
+var unsafeNameRegex = regexp.MustCompile(`[/\\:*?"<>|#^\[\]]+`)
+
+// localRefRegex matches Markdown links and images pointing at a file within
+// the zet directory, such as those added by `zet attach`.
+var localRefRegex = regexp.MustCompile(`(!?\[[^\]]*\])\(([^)\s/:]+)\)`)
+
+type ExportCmd struct {
+	Obsidian ExportObsidianCmd `cmd:"" help:"Export zets as a flat Obsidian vault"`
+	Logseq   ExportLogseqCmd   `cmd:"" help:"Export zets as a Logseq graph"`
+}
+
+type ExportObsidianCmd struct {
+	Out string `help:"Directory to write the vault to" required:"" type:"path"`
+}
+
+func (c *ExportObsidianCmd) Run() error {
+	z := new(Zet)
+	n, skipped, err := z.ExportObsidian(c.Out)
+	if err != nil {
+		return err
+	}
+	printExported(n, skipped, c.Out)
+	return nil
+}
+
+type ExportLogseqCmd struct {
+	Out string `help:"Directory to write the graph to" required:"" type:"path"`
+}
+
+func (c *ExportLogseqCmd) Run() error {
+	z := new(Zet)
+	n, skipped, err := z.ExportLogseq(c.Out)
+	if err != nil {
+		return err
+	}
+	printExported(n, skipped, c.Out)
+	return nil
+}
+
+func printExported(n, skipped int, out string) {
+	fmt.Printf("Exported %d zets to %s\n", n, out)
+	if skipped > 0 {
+		fmt.Printf("Skipped %d private zets\n", skipped)
+	}
+}
+
+// exportNote is a zet prepared for writing into another app's layout.
+type exportNote struct {
+	Id          string
+	Title       string
+	Name        string
+	Body        string
+	Tags        []string
+	Journal     string
+	Attachments []string
+}
+
+// exportNotes reads every public zet and assigns each a unique file name
+// derived from its title. Private zets are never exported as that would
+// write their plaintext outside the repo; the number skipped is returned.
+func (z *Zet) exportNotes() ([]exportNote, int, error) {
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return nil, 0, err
+	}
+	var notes []exportNote
+	var skipped int
+	used := make(map[string]bool)
+	for _, f := range files {
+		dir := filepath.Join(Repo, f)
+		if isPrivate(dir) {
+			skipped++
+			continue
+		}
+		c, err := readReadme(dir)
+		if isMissing(f, err) {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		fm, body := splitFrontMatter(c)
+		n := exportNote{
+			Id:      f,
+			Title:   parseTitle(c),
+			Body:    strings.TrimSpace(stripTagLines(body)) + "\n",
+			Tags:    parseTags(body),
+			Journal: fm[journalKey],
+		}
+		if m := journalTitleRegex.FindStringSubmatch(n.Title); n.Journal == "" && m != nil {
+			n.Journal = m[1]
+		}
+		n.Name = vaultName(n.Title)
+		if n.Name == "" || used[strings.ToLower(n.Name)] {
+			n.Name = strings.TrimSpace(n.Name + " " + f)
+		}
+		used[strings.ToLower(n.Name)] = true
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, e := range entries {
+			if e.Type().IsRegular() && e.Name() != "README.md" {
+				n.Attachments = append(n.Attachments, e.Name())
+			}
+		}
+		notes = append(notes, n)
+	}
+	return notes, skipped, nil
+}
+
+// ExportObsidian writes every public zet to out as a flat Obsidian vault. Each
+// zet becomes Title.md with its id in the front matter, links between zets
+// become [[Title]] links and attachments are copied into an assets folder.
+func (z *Zet) ExportObsidian(out string) (int, int, error) {
+	notes, skipped, err := z.exportNotes()
+	if err != nil {
+		return 0, 0, err
+	}
+	names := exportNames(notes)
+	for _, n := range notes {
+		body, err := exportAttachments(out, n, exportAssets)
+		if err != nil {
+			return 0, 0, err
+		}
+		body = wikiLinks(body, names)
+		var fm strings.Builder
+		fmt.Fprintf(&fm, "---\nid: %q\n", n.Id)
+		if t, ok := IdTime(n.Id); ok {
+			fmt.Fprintf(&fm, "created: %s\n", t.Format(time.RFC3339))
+		}
+		if len(n.Tags) > 0 {
+			fmt.Fprintf(&fm, "tags: [%s]\n", strings.Join(n.Tags, ", "))
+		}
+		fm.WriteString("---\n")
+		err = writeExport(filepath.Join(out, n.Name+".md"), fm.String()+body)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(notes), skipped, nil
+}
+
+// ExportLogseq writes every public zet to out as a Logseq graph. Journals are
+// written to journals/ named by date and everything else to pages/, with the
+// zet id and tags kept as page properties. Links between zets become [[Page]]
+// links and attachments are copied into assets/.
+func (z *Zet) ExportLogseq(out string) (int, int, error) {
+	notes, skipped, err := z.exportNotes()
+	if err != nil {
+		return 0, 0, err
+	}
+	// journals are linked by the date Logseq shows as their page name
+	for i, n := range notes {
+		if t, err := time.Parse(dateLayout, n.Journal); err == nil {
+			notes[i].Name = logseqDate(t)
+		}
+	}
+	names := exportNames(notes)
+	for _, n := range notes {
+		body, err := exportAttachments(out, n, "../"+exportAssets)
+		if err != nil {
+			return 0, 0, err
+		}
+		body = wikiLinks(body, names)
+		var props strings.Builder
+		fmt.Fprintf(&props, "zet-id:: %s\n", n.Id)
+		if len(n.Tags) > 0 {
+			fmt.Fprintf(&props, "tags:: %s\n", strings.Join(n.Tags, ", "))
+		}
+		p := filepath.Join(out, "pages", strings.ReplaceAll(n.Name, "/", "___")+".md")
+		if t, err := time.Parse(dateLayout, n.Journal); err == nil {
+			p = filepath.Join(out, "journals", t.Format("2006_01_02")+".md")
+		}
+		err = writeExport(p, props.String()+"\n"+body)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(notes), skipped, nil
+}
+
+// exportNames maps zet ids to the page name each was exported under.
+func exportNames(notes []exportNote) map[string]string {
+	names := make(map[string]string, len(notes))
+	for _, n := range notes {
+		names[n.Id] = n.Name
+	}
+	return names
+}
+
+// exportAttachments copies the attachments of n into the assets folder of out,
+// prefixed with the zet id to keep names unique in the flat folder. References
+// to them in the body are rewritten relative to the exported note using prefix.
+func exportAttachments(out string, n exportNote, prefix string) (string, error) {
+	if len(n.Attachments) == 0 {
+		return n.Body, nil
+	}
+	err := mkdir(filepath.Join(out, exportAssets))
+	if err != nil {
+		return "", err
+	}
+	renamed := make(map[string]string, len(n.Attachments))
+	for _, a := range n.Attachments {
+		name := n.Id + "-" + a
+		dst := filepath.Join(out, exportAssets, name)
+		_ = os.Remove(dst)
+		err := copyFile(filepath.Join(Repo, n.Id, a), dst)
+		if err != nil {
+			return "", err
+		}
+		renamed[a] = prefix + "/" + url.PathEscape(name)
+	}
+	return localRefRegex.ReplaceAllStringFunc(n.Body, func(s string) string {
+		m := localRefRegex.FindStringSubmatch(s)
+		name, err := url.PathUnescape(m[2])
+		if err != nil {
+			return s
+		}
+		if r, ok := renamed[name]; ok {
+			return m[1] + "(" + r + ")"
+		}
+		return s
+	}), nil
+}
+
+// wikiLinks rewrites Markdown links between zets as [[Name]] links, keeping
+// custom link text as an alias. Links to zets which were not exported are
+// left as they are.
+func wikiLinks(body string, names map[string]string) string {
+	return zetLinkRegex.ReplaceAllStringFunc(body, func(s string) string {
+		m := zetLinkRegex.FindStringSubmatch(s)
+		name, ok := names[m[3]]
+		if !ok {
+			return s
+		}
+		text := strings.TrimSpace(m[1])
+		if text == "" || text == name {
+			return "[[" + name + "]]"
+		}
+		return "[[" + name + "|" + text + "]]"
+	})
+}
+
+// writeExport writes content to path creating any parent directories.
+func writeExport(path, content string) error {
+	err := mkdir(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// vaultName returns title made safe for use as a file name and page link.
+func vaultName(title string) string {
+	return strings.TrimSpace(unsafeNameRegex.ReplaceAllString(title, "-"))
+}
+
+// logseqDate formats t as Logseq's default journal page title, e.g.
+// "Oct 19th, 2026".
+func logseqDate(t time.Time) string {
+	d := t.Day()
+	suffix := "th"
+	if d < 11 || d > 13 {
+		switch d % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%s %d%s, %d", t.Format("Jan"), d, suffix, t.Year())
+}
@@ -0,0 +1,118 @@
+package zet
+
+import (
+	"strings"
+)
+
+// frontMatterFence opens and closes the optional YAML front matter block at
+// the top of a zet README.md.
+const frontMatterFence = "---"
+
+// splitFrontMatter separates the front matter from the body of a README.md.
+// Only flat `key: value` pairs are supported which is all zet requires. If
+// the content does not start with a fence the front matter is nil and the
+// body is the content unchanged.
+func splitFrontMatter(content string) (map[string]string, string) {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != frontMatterFence {
+		return nil, content
+	}
+	fm := make(map[string]string)
+	for i := 1; i < len(lines); i++ {
+		l := strings.TrimSpace(lines[i])
+		if l == frontMatterFence {
+			return fm, strings.Join(lines[i+1:], "")
+		}
+		k, v, ok := strings.Cut(l, ":")
+		if !ok {
+			continue
+		}
+		fm[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"'`)
+	}
+	// unterminated front matter is treated as regular content
+	return nil, content
+}
+
+// setFrontMatter replaces the value of key within the front matter of content.
+// It reports false if the content has no front matter or the key is missing.
+func setFrontMatter(content, key, value string) (string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != frontMatterFence {
+		return content, false
+	}
+	for i := 1; i < len(lines); i++ {
+		l := strings.TrimSpace(lines[i])
+		if l == frontMatterFence {
+			break
+		}
+		k, _, ok := strings.Cut(l, ":")
+		if ok && strings.TrimSpace(k) == key {
+			lines[i] = key + ": " + value + "\n"
+			return strings.Join(lines, ""), true
+		}
+	}
+	return content, false
+}
+
+// parseTitle returns the title of a README.md. A `title` front matter entry
+// takes precedence, followed by the line found by titleLine with any '#'
+// removed.
+func parseTitle(content string) string {
+	fm, body := splitFrontMatter(content)
+	if t := fm["title"]; t != "" {
+		return t
+	}
+	lines := strings.Split(body, "\n")
+	i := titleLine(lines)
+	if i < 0 {
+		return ""
+	}
+	if strings.HasPrefix(lines[i], "# ") {
+		return strings.TrimSpace(strings.TrimPrefix(lines[i], "# "))
+	}
+	return strings.TrimSpace(strings.Replace(lines[i], "#", "", -1))
+}
+
+// setTitle rewrites the title of a README.md, preferring the front matter
+// `title` entry when present and otherwise the line found by titleLine.
+func setTitle(content, title string) string {
+	if c, ok := setFrontMatter(content, "title", title); ok {
+		return c
+	}
+	_, body := splitFrontMatter(content)
+	head := content[:len(content)-len(body)]
+	lines := strings.SplitAfter(body, "\n")
+	if i := titleLine(lines); i >= 0 {
+		lines[i] = "# " + title + "\n"
+		return head + strings.Join(lines, "")
+	}
+	return head + "# " + title + "\n\n" + body
+}
+
+// titleLine returns the index of the line holding the title of a README.md
+// body: the first line which is not blank, unless it opens a code block, in
+// which case the first h1 outside of code blocks. It returns -1 if there is
+// no such line.
+func titleLine(lines []string) int {
+	fence, first := "", true
+	for i, l := range lines {
+		t := strings.TrimSpace(l)
+		if fence != "" {
+			if strings.HasPrefix(t, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~") {
+			fence, first = t[:3], false
+			continue
+		}
+		if t == "" {
+			continue
+		}
+		if first || strings.HasPrefix(l, "# ") {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,43 @@
+package zet
+
+import "testing"
+
+func TestParseTitle(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"h1", "# Title\n\nBody\n", "Title"},
+		{"front matter", "---\ntitle: Front\n---\n# Title\n", "Front"},
+		{"first line", "\nPlain #title\n\n# Later\n", "Plain title"},
+		{"backtick fence", "```sh\n# comment in code\n```\n\n# Title\n", "Title"},
+		{"tilde fence", "~~~\n# comment in code\n~~~\n# Title\n", "Title"},
+		{"fenced only", "```\n# comment in code\n```\n", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTitle(tt.content); got != tt.want {
+				t.Errorf("parseTitle(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTitle(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"h1", "# Old\n\nBody\n", "# New\n\nBody\n"},
+		{"front matter", "---\ntitle: Old\n---\n# Old\n", "---\ntitle: New\n---\n# Old\n"},
+		{"backtick fence", "```sh\n# comment\n```\n\n# Old\n", "```sh\n# comment\n```\n\n# New\n"},
+		{"tilde fence", "~~~\n# comment\n~~~\n# Old\n", "~~~\n# comment\n~~~\n# New\n"},
+		{"no title", "```\n# comment\n```\n", "# New\n\n```\n# comment\n```\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := setTitle(tt.content, "New"); got != tt.want {
+				t.Errorf("setTitle(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
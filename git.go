@@ -1,10 +1,16 @@
 package zet
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/danielmichaels/zet-cmd/internal/term"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 )
 
 type GitCmd struct {
@@ -13,7 +19,7 @@ type GitCmd struct {
 
 func (c *GitCmd) Run() error {
 	z := new(Zet)
-	err := z.ChangeDir(z.GetRepo())
+	err := z.checkRepo()
 	if err != nil {
 		return err
 	}
@@ -37,20 +43,27 @@ func (c *GitCmd) Run() error {
 	default:
 		return errors.New("invalid git command")
 	}
-	if err := term.Exec(cmdArgs...); err != nil {
+	if err := term.ExecIn(Repo, cmdArgs...); err != nil {
 		return err
 	}
 	return nil
 }
 
+// GitTimeout bounds each git operation run while committing a zet, such as a
+// pull or push over a slow network. Zero disables the timeout.
+var GitTimeout = 2 * time.Minute
+
 // scanAndCommit checks that the user wants to commit their work to the VCS
-// and pushes the commit if they accept.
-func (z *Zet) scanAndCommit(zet string) error {
+// and pushes the commit if they accept, returning ErrUserDeclinedCommit if
+// they do not.
+func (z *Zet) scanAndCommit(ctx context.Context, zet string) error {
+	if z.Id == "" && regexp.MustCompile(zetRegex).MatchString(filepath.Base(zet)) {
+		z.Id = filepath.Base(zet)
+	}
 	if term.Prompt("Commit? (y/N) ") != "y" {
-		fmt.Printf("%q not commited but modified\n", zet)
-		os.Exit(0)
+		return fmt.Errorf("%w, %s modified but not committed", ErrUserDeclinedCommit, filepath.Base(zet))
 	}
-	err := z.PullAddCommitPush()
+	err := z.PullAddCommitPush(ctx)
 	if err != nil {
 		return err
 	}
@@ -58,104 +71,195 @@ func (z *Zet) scanAndCommit(zet string) error {
 }
 
 // PullAddCommitPush is a helper method which flows through a Git workflow
-// and is called often in Commands such as `create` and `edit`.
-func (z *Zet) PullAddCommitPush() error {
+// and is called often in Commands such as `create` and `edit`. Each zet being
+// committed is checked with Validate first and the pre-commit and post-commit
+// user hooks run around the commit. Each git step is bounded by GitTimeout and
+// stops early if ctx is cancelled.
+func (z *Zet) PullAddCommitPush(ctx context.Context) error {
+	r := regexp.MustCompile(zetRegex)
+	for _, p := range z.paths() {
+		if !r.MatchString(filepath.Base(p)) {
+			continue
+		}
+		// zets removed by the command have nothing left to validate
+		if _, err := os.Stat(p); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		err := z.checkZet(ctx, p)
+		if err != nil {
+			return err
+		}
+	}
 	if z.Title == "" {
 		err := z.GetTitle()
 		if err != nil {
 			return errors.New("failed to ascertain zet title")
 		}
 	}
-	err := z.Pull()
+	err := z.checkPlaintext()
+	if err != nil {
+		return err
+	}
+	err = z.Pull(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to pull from git remote: %w", err)
 	}
-	err = z.Add()
+	err = z.runHook(ctx, HookPreCommit)
+	if err != nil {
+		return err
+	}
+	err = z.Add(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to add files to git: %w", err)
 	}
-	err = z.Commit()
+	err = z.Commit(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to commit files to git: %w", err)
 	}
-	err = z.Push()
+	err = z.Push(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to push files to git: %w", err)
 	}
-	return nil
+	return z.runHook(ctx, HookPostCommit)
+}
+
+// git runs a git subcommand within the zet repo bounded by GitTimeout.
+func git(ctx context.Context, args ...string) error {
+	return gitEnv(ctx, nil, args...)
+}
+
+// gitEnv is git with env added to the environment of the command.
+func gitEnv(ctx context.Context, env []string, args ...string) error {
+	ctx, cancel := gitContext(ctx)
+	defer cancel()
+	err := gitErr(term.ExecContextEnv(ctx, Repo, env, append([]string{"git"}, args...)...))
+	logGit(args, err)
+	return err
+}
+
+// gitOut is git returning the standard output of the command.
+func gitOut(ctx context.Context, args ...string) (string, error) {
+	ctx, cancel := gitContext(ctx)
+	defer cancel()
+	out, err := term.OutContext(ctx, Repo, append([]string{"git"}, args...)...)
+	err = gitErr(err)
+	logGit(args, err, "bytes", len(out))
+	return out, err
+}
+
+// logGit logs the result of a git subcommand at debug level.
+func logGit(args []string, err error, attrs ...any) {
+	attrs = append([]any{"subcommand", args[0], "ok", err == nil}, attrs...)
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	slog.Debug("git", attrs...)
+}
+
+// gitContext returns a context for a single git operation, bounded by
+// GitTimeout when set.
+func gitContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if GitTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, GitTimeout)
+}
+
+// gitErr replaces the generic deadline error with one naming the timeout so
+// it is clear how to raise it.
+func gitErr(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w (timeout %s, set ZET_GIT_TIMEOUT to change)", err, GitTimeout)
+	}
+	return err
 }
 
 // GitRemote checks for the existence of a non-empty `git remote -v` response.
-func (z *Zet) GitRemote() error {
+func (z *Zet) GitRemote(ctx context.Context) error {
 	if os.Getenv("GIT_REMOTE") != "" {
 		return nil
 	}
-	s := term.Out("git", "remote", "-v")
+	s, err := gitOut(ctx, "remote", "-v")
+	if err != nil {
+		return err
+	}
 	if s == "" {
 		return errors.New("no git remote found")
 	}
 	return nil
 }
 
-// Pull changes the current directory to the repository, verifies the git remote, and pulls the latest changes
-// from the remote repository with quiet output.
-func (z *Zet) Pull() error {
-	err := z.ChangeDir(Repo)
-	if err != nil {
-		return err
-	}
-	err = z.GitRemote()
+// Pull verifies the git remote and pulls the latest changes from the remote repository with quiet output.
+func (z *Zet) Pull(ctx context.Context) error {
+	err := z.GitRemote(ctx)
 	if err != nil {
 		return err
 	}
-	err = term.Exec("git", "pull", "-q")
+	err = git(ctx, "pull", "-q")
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// Add stages all files in the Zet's path to the git repository by changing to the repository directory
-// and executing a git add command with the all (-A) flag.
-func (z *Zet) Add() error {
-	err := z.ChangeDir(Repo)
-	if err != nil {
-		return err
+// Add stages all files in the Zet's path to the git repository by executing a git add command with the
+// all (-A) flag. The private zet salt is staged alongside as
+// private zets cannot be decrypted on another machine without it.
+func (z *Zet) Add(ctx context.Context) error {
+	return z.add(ctx, z.paths()...)
+}
+
+// paths returns the directories of the zets in Ids when a command changed
+// several zets at once, otherwise Path.
+func (z *Zet) paths() []string {
+	if len(z.Ids) == 0 {
+		return []string{z.Path}
+	}
+	var paths []string
+	for _, id := range z.Ids {
+		paths = append(paths, filepath.Join(Repo, id))
 	}
-	err = term.Exec("git", "add", "-A", z.Path)
+	return paths
+}
+
+// add is Add for any number of paths, such as the zets committed together by
+// `zet commit`.
+func (z *Zet) add(ctx context.Context, paths ...string) error {
+	salt := filepath.Join(Repo, zetConfigDir, saltFile)
+	if _, err := os.Stat(salt); err == nil {
+		paths = append(paths, salt)
+	}
+	err := git(ctx, append([]string{"add", "-A"}, paths...)...)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// Commit stages and commits the current changes in the Zet repository with the Zet's title as the commit message.
-// It changes the current directory to the repository, executes a git commit command, and prints a confirmation message.
-func (z *Zet) Commit() error {
-	err := z.ChangeDir(Repo)
+// Commit commits the staged changes in the Zet repository with a message rendered from CommitTemplate.
+// It executes a git commit command and prints a confirmation message.
+func (z *Zet) Commit(ctx context.Context) error {
+	msg, err := z.commitMessage(ctx)
 	if err != nil {
 		return err
 	}
-	err = term.Exec("git", "commit", "-m", z.Title)
+	// zet validates what it commits itself, see preCommitHook
+	err = gitEnv(ctx, []string{validatedEnv + "=1"}, "commit", "-m", msg)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Committed %q\n", z.Title)
+	subject, _, _ := strings.Cut(msg, "\n")
+	fmt.Printf("Committed %q\n", subject)
 	return nil
 }
 
-// Push changes the current directory to the repository, verifies the git remote, and pushes the current branch
-// to the remote repository with quiet output.
-func (z *Zet) Push() error {
-	err := z.ChangeDir(Repo)
-	if err != nil {
-		return err
-	}
-	err = z.GitRemote()
+// Push verifies the git remote and pushes the current branch to the remote repository with quiet output.
+func (z *Zet) Push(ctx context.Context) error {
+	err := z.GitRemote(ctx)
 	if err != nil {
 		return err
 	}
-	err = term.Exec("git", "push", "--quiet")
+	err = git(ctx, "push", "--quiet")
 	if err != nil {
 		return err
 	}
@@ -5,6 +5,8 @@ go 1.24
 require (
 	github.com/alecthomas/kong v1.10.0
 	github.com/charmbracelet/glamour v0.9.1
+	github.com/yuin/goldmark v1.7.8
+	golang.org/x/term v0.30.0
 )
 
 require (
@@ -26,10 +28,8 @@ require (
 	github.com/muesli/termenv v0.16.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
-	github.com/yuin/goldmark v1.7.8 // indirect
 	github.com/yuin/goldmark-emoji v1.0.5 // indirect
 	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
-	golang.org/x/term v0.30.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
 )
@@ -0,0 +1,189 @@
+package zet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// preCommitHook is the git pre-commit hook installed by `zet hooks install`.
+// Commits made by zet have already been validated so are let straight through.
+// ZETDIR is set to the repository being committed to as it is often unset in
+// the shell or editor running git.
+const preCommitHook = `#!/bin/sh
+# Installed by zet hooks install: validates staged zets before committing.
+[ -n "$ZET_VALIDATED" ] && exit 0
+ZETDIR="$(git rev-parse --show-toplevel)" || exit 1
+export ZETDIR
+exec zet hooks pre-commit
+`
+
+// validatedEnv is set while zet commits so that the pre-commit hook does not
+// validate the same zets a second time.
+const validatedEnv = "ZET_VALIDATED"
+
+type HooksCmd struct {
+	Install   HooksInstallCmd   `cmd:"" help:"Install a git pre-commit hook validating zets committed outside zet"`
+	PreCommit HooksPreCommitCmd `cmd:"" hidden:"" help:"Validate the staged zets, run by the git pre-commit hook"`
+}
+
+type HooksInstallCmd struct {
+	Force bool `help:"Replace an existing pre-commit hook"`
+}
+
+func (c *HooksInstallCmd) Run(ctx context.Context) error {
+	z := new(Zet)
+	err := z.checkRepo()
+	if err != nil {
+		return err
+	}
+	// hooks may live outside .git when core.hooksPath is set
+	dir, err := gitOut(ctx, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+	dir = strings.TrimSpace(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(Repo, dir)
+	}
+	hook := filepath.Join(dir, "pre-commit")
+	if b, err := os.ReadFile(hook); err == nil && string(b) != preCommitHook && !c.Force {
+		return fmt.Errorf("%s already exists, use --force to replace it", hook)
+	}
+	err = mkdir(dir)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(hook, []byte(preCommitHook), 0755)
+	if err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	err = os.Chmod(hook, 0755)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s\n", hook)
+	return nil
+}
+
+type HooksPreCommitCmd struct{}
+
+func (c *HooksPreCommitCmd) Run(ctx context.Context) error {
+	z := new(Zet)
+	err := z.checkRepo()
+	if err != nil {
+		return err
+	}
+	return z.validateStaged(ctx)
+}
+
+// validateStaged runs Validate over the README.md of every zet staged for
+// commit, as it is in the index. Private zets are skipped as only their
+// ciphertext is staged.
+func (z *Zet) validateStaged(ctx context.Context) error {
+	out, err := gitOut(ctx, "diff", "--cached", "--name-only", "--diff-filter=d")
+	if err != nil {
+		return err
+	}
+	r := regexp.MustCompile(zetRegex)
+	var ids []string
+	seen := make(map[string]bool)
+	for _, l := range strings.Split(out, "\n") {
+		if id := zetOf(l); r.MatchString(id) && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	var failed []string
+	for _, id := range ids {
+		c, err := gitOut(ctx, "show", ":"+id+"/README.md")
+		if err != nil {
+			continue
+		}
+		titles, err := z.linkTitles(ctx, c)
+		if err != nil {
+			return err
+		}
+		if p := Validate(c, titles); len(p) > 0 {
+			fmt.Println(&ValidationError{Id: id, Problems: p})
+			failed = append(failed, id)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New("invalid zets " + strings.Join(failed, ", ") + ", fix them or commit with --no-verify")
+	}
+	return nil
+}
+
+// User hooks are executables in $ZETDIR/.zet/hooks named after the event
+// they run on, so they sync with the zets through git.
+const (
+	HookPostCreate = "post-create"
+	HookPreCommit  = "pre-commit"
+	HookPostCommit = "post-commit"
+	HookPostDelete = "post-delete"
+)
+
+// userHooksDir is the directory within zetConfigDir holding user hooks.
+const userHooksDir = "hooks"
+
+// HookData is passed to user hooks as JSON on stdin.
+type HookData struct {
+	Hook  string   `json:"hook"`
+	Op    string   `json:"op,omitempty"`
+	Id    string   `json:"id,omitempty"`
+	Ids   []string `json:"ids,omitempty"`
+	Path  string   `json:"path,omitempty"`
+	Title string   `json:"title,omitempty"`
+}
+
+// runHook runs the user hook name, if one is installed and executable, from
+// the repo with the zet passed as ZET_* environment variables and as HookData
+// on stdin. A failing pre-commit hook stops the commit. Other hooks run after
+// the fact so their failure is only reported.
+func (z *Zet) runHook(ctx context.Context, name string) error {
+	hook := filepath.Join(Repo, zetConfigDir, userHooksDir, name)
+	fi, err := os.Stat(hook)
+	if err != nil || fi.IsDir() || fi.Mode()&0111 == 0 {
+		slog.Debug("no hook", "hook", name)
+		return nil
+	}
+	d := HookData{Hook: name, Op: z.Op, Id: z.Id, Ids: z.Ids, Path: z.Path}
+	d.Title, _, _ = strings.Cut(z.Title, "\n\n")
+	d.Title = publicTitle(z.Id, d.Title)
+	if len(d.Ids) == 0 && d.Id != "" {
+		d.Ids = []string{d.Id}
+	}
+	in, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	env := []string{
+		"ZET_HOOK=" + d.Hook,
+		"ZET_OP=" + d.Op,
+		"ZET_ID=" + d.Id,
+		"ZET_IDS=" + strings.Join(d.Ids, " "),
+		"ZET_PATH=" + d.Path,
+		"ZET_TITLE=" + d.Title,
+		"ZET_REPO=" + Repo,
+	}
+	err = term.PipeContext(ctx, Repo, env, string(in)+"\n", hook)
+	if err == nil {
+		return nil
+	}
+	if name == HookPreCommit {
+		return fmt.Errorf("%s hook: %w", name, err)
+	}
+	fmt.Printf("%s hook failed: %v\n", name, err)
+	return nil
+}
@@ -0,0 +1,128 @@
+package zet
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Supported schemes for generating zet ids, selected with ZET_ID_SCHEME.
+const (
+	SchemeIsosec   = "isosec"
+	SchemeIsosecMs = "isosec-ms"
+	SchemeULID     = "ulid"
+)
+
+const (
+	// idPattern matches an id from any supported scheme. Both isosec schemes
+	// are digits only while a ULID is 26 characters of Crockford's base32.
+	idPattern = `[0-9]{14,}|[` + crockford + `]{26}`
+	crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	// maxIdAttempts bounds the retries when an id is already taken.
+	maxIdAttempts = 100
+)
+
+// NewId returns a zet id for t generated by the configured IdScheme.
+func NewId(t time.Time) (string, error) {
+	t = t.In(time.UTC)
+	switch IdScheme {
+	case "", SchemeIsosec:
+		return t.Format("20060102150405"), nil
+	case SchemeIsosecMs:
+		return strings.Replace(t.Format("20060102150405.000"), ".", "", 1), nil
+	case SchemeULID:
+		return ulid(t)
+	default:
+		return "", fmt.Errorf("unknown id scheme %q", IdScheme)
+	}
+}
+
+// idResolution is the smallest step in time which yields a different id for
+// the configured IdScheme.
+func idResolution() time.Duration {
+	switch IdScheme {
+	case SchemeIsosecMs, SchemeULID:
+		return time.Millisecond
+	default:
+		return time.Second
+	}
+}
+
+// CreateDirAt atomically creates a zet directory named with an id for t. If
+// the id is taken, for instance by a script creating several zets within the
+// same second, t is advanced by the id resolution and creation is retried.
+func (z *Zet) CreateDirAt(t time.Time) (string, error) {
+	err := mkdir(Repo)
+	if err != nil {
+		return "", err
+	}
+	for i := 0; i < maxIdAttempts; i++ {
+		id, err := NewId(t)
+		if err != nil {
+			return "", err
+		}
+		path := filepath.Join(Repo, id)
+		err = os.Mkdir(path, 0755)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+		t = t.Add(idResolution())
+	}
+	return "", fmt.Errorf("no free zet id found after %d attempts", maxIdAttempts)
+}
+
+// ulid returns a Universally Unique Lexicographically Sortable Identifier
+// made from the milliseconds of t and 80 random bits.
+func ulid(t time.Time) (string, error) {
+	var b [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	// encode 128 bits as 26 base32 characters, the first holding 3 bits
+	out := make([]byte, 26)
+	var acc uint
+	var bits uint
+	j := 25
+	for i := 15; i >= 0; i-- {
+		acc |= uint(b[i]) << bits
+		bits += 8
+		for bits >= 5 {
+			out[j] = crockford[acc&31]
+			acc >>= 5
+			bits -= 5
+			j--
+		}
+	}
+	out[0] = crockford[acc&31]
+	return string(out), nil
+}
+
+// ulidTime decodes the millisecond timestamp held in the first ten
+// characters of a ULID.
+func ulidTime(id string) (time.Time, bool) {
+	if len(id) != 26 {
+		return time.Time{}, false
+	}
+	var ms uint64
+	for _, c := range id[:10] {
+		i := strings.IndexRune(crockford, c)
+		if i < 0 {
+			return time.Time{}, false
+		}
+		ms = ms<<5 | uint64(i)
+	}
+	return time.UnixMilli(int64(ms)).In(time.UTC), true
+}
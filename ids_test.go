@@ -0,0 +1,161 @@
+package zet
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withScheme sets IdScheme for the duration of the test.
+func withScheme(t *testing.T, scheme string) {
+	t.Helper()
+	old := IdScheme
+	t.Cleanup(func() { IdScheme = old })
+	IdScheme = scheme
+}
+
+func TestNewId(t *testing.T) {
+	at := time.Date(2022, 1, 2, 12, 30, 45, 678900000, time.FixedZone("AEST", 10*60*60))
+	reg := regexp.MustCompile(zetRegex)
+	tests := []struct {
+		name, scheme, want string
+	}{
+		{"default", "", "20220102023045"},
+		{"isosec", SchemeIsosec, "20220102023045"},
+		{"isosec-ms", SchemeIsosecMs, "20220102023045678"},
+		{"ulid", SchemeULID, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withScheme(t, tt.scheme)
+			id, err := NewId(at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reg.MatchString(id) {
+				t.Errorf("NewId() = %q, does not match %s", id, zetRegex)
+			}
+			if tt.want != "" && id != tt.want {
+				t.Errorf("NewId() = %q, want %q", id, tt.want)
+			}
+		})
+	}
+
+	withScheme(t, "uuid")
+	if _, err := NewId(at); err == nil {
+		t.Error("NewId() with an unknown scheme returned no error")
+	}
+}
+
+func TestIdTime(t *testing.T) {
+	at := time.Date(2022, 1, 2, 12, 30, 45, 678900000, time.UTC)
+	tests := []struct {
+		scheme string
+		want   time.Time
+	}{
+		{SchemeIsosec, at.Truncate(time.Second)},
+		{SchemeIsosecMs, at.Truncate(time.Second)},
+		{SchemeULID, at.Truncate(time.Millisecond)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			withScheme(t, tt.scheme)
+			id, err := NewId(at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := IdTime(id)
+			if !ok || !got.Equal(tt.want) {
+				t.Errorf("IdTime(%q) = %v, %v, want %v, true", id, got, ok, tt.want)
+			}
+		})
+	}
+
+	for _, id := range []string{"", "2022", "notanid", "99999999999999", strings.Repeat("U", 26)} {
+		if got, ok := IdTime(id); ok {
+			t.Errorf("IdTime(%q) = %v, true, want false", id, got)
+		}
+	}
+}
+
+func TestULID(t *testing.T) {
+	tests := []struct {
+		ms   int64
+		want string
+	}{
+		// the timestamp from the ULID specification's example
+		{1469918176385, "01ARYZ6S41"},
+		{0, "0000000000"},
+		{1<<48 - 1, "7ZZZZZZZZZ"},
+	}
+	for _, tt := range tests {
+		id, err := ulid(time.UnixMilli(tt.ms))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(id) != 26 {
+			t.Errorf("ulid() = %q, want 26 characters", id)
+		}
+		if strings.Trim(id, crockford) != "" {
+			t.Errorf("ulid() = %q, want only Crockford base32 characters", id)
+		}
+		if id[:10] != tt.want {
+			t.Errorf("ulid(%d) timestamp = %q, want %q", tt.ms, id[:10], tt.want)
+		}
+		if got, ok := ulidTime(id); !ok || got.UnixMilli() != tt.ms {
+			t.Errorf("ulidTime(%q) = %v, %v, want %d ms", id, got, ok, tt.ms)
+		}
+	}
+
+	a, _ := ulid(time.UnixMilli(0))
+	b, _ := ulid(time.UnixMilli(0))
+	if a == b {
+		t.Errorf("ulid() returned %q twice, want random bits", a)
+	}
+}
+
+func TestCreateDirAt(t *testing.T) {
+	old := Repo
+	t.Cleanup(func() { Repo = old })
+	at := time.Date(2022, 1, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		scheme string
+		want   []string
+	}{
+		{SchemeIsosec, []string{"20220102120000", "20220102120001", "20220102120002"}},
+		{SchemeIsosecMs, []string{"20220102120000000", "20220102120000001", "20220102120000002"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			withScheme(t, tt.scheme)
+			Repo = t.TempDir()
+			z := new(Zet)
+			for _, want := range tt.want {
+				path, err := z.CreateDirAt(at)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if path != filepath.Join(Repo, want) {
+					t.Errorf("CreateDirAt() = %q, want %q", path, filepath.Join(Repo, want))
+				}
+			}
+		})
+	}
+
+	t.Run("exhausted", func(t *testing.T) {
+		withScheme(t, SchemeIsosec)
+		Repo = t.TempDir()
+		for i := 0; i < maxIdAttempts; i++ {
+			err := os.Mkdir(filepath.Join(Repo, at.Add(time.Duration(i)*time.Second).Format("20060102150405")), 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if path, err := new(Zet).CreateDirAt(at); err == nil {
+			t.Errorf("CreateDirAt() = %q, want an error once every attempt is taken", path)
+		}
+	})
+}
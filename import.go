@@ -0,0 +1,444 @@
+package zet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// importWikiRegex matches [[Name]], [[Name#Heading|text]] and embeds such
+	// as ![[image.png]]. The groups are the embed '!', the target and the
+	// optional link text.
+	importWikiRegex = regexp.MustCompile(`(!?)\[\[([^\]|#]*)(?:#[^\]|]*)?(?:\|([^\]]*))?\]\]`)
+	// importLinkRegex matches Markdown links and images. The groups are the
+	// image '!', the link text and the target, which may be wrapped in <>.
+	importLinkRegex = regexp.MustCompile(`(!?)\[([^\]]*)\]\(<?([^)<>]+?)>?(?:\s+"[^"]*")?\)`)
+	// importTagRegex matches inline tags such as #idea or Bear's nested
+	// #work/meetings. Tags must contain a non-digit so "#1" is left alone.
+	importTagRegex = regexp.MustCompile(`(^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+	// notionIdRegex matches the id Notion appends to exported file names.
+	notionIdRegex = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+)
+
+type ImportCmd struct {
+	Dir    string `arg:"" help:"Folder of Markdown files, such as an Obsidian vault or a Notion or Bear export" type:"existingdir"`
+	DryRun bool   `help:"Print which zet each file would become without importing anything"`
+}
+
+func (c *ImportCmd) Run(ctx context.Context) error {
+	z := new(Zet)
+	im, err := z.PlanImport(c.Dir)
+	if err != nil {
+		return err
+	}
+	if len(im.notes) == 0 {
+		return fmt.Errorf("no Markdown files found in %q", c.Dir)
+	}
+	for _, n := range im.notes {
+		fmt.Printf("%s → %s %s\n", n.Src, n.Id, n.Title)
+	}
+	if c.DryRun {
+		fmt.Printf("%d files would be imported\n", len(im.notes))
+		return nil
+	}
+	err = im.Import()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d files\n", len(im.notes))
+
+	// Every imported zet lands in a single commit.
+	z.Path = Repo
+	for _, n := range im.notes {
+		z.Ids = append(z.Ids, n.Id)
+	}
+	z.Op = OpImport
+	z.Title = fmt.Sprintf("%d zets from %s", len(im.notes), filepath.Base(c.Dir))
+	err = z.scanAndCommit(ctx, z.Path)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// importNote is a Markdown file to be imported and the zet it will become.
+type importNote struct {
+	Src   string
+	Id    string
+	Title string
+	Time  time.Time
+}
+
+// Importer converts a folder of Markdown files into zets.
+type Importer struct {
+	root   string
+	notes  []*importNote
+	byPath map[string]*importNote
+	byName map[string]*importNote
+	files  map[string]string
+}
+
+// PlanImport walks dir for Markdown files and assigns each a zet id seeded
+// from its modification time, so the zets keep the order they were written
+// in. Nothing is written until Import is called. Hidden folders, such as
+// .obsidian or .git, are skipped.
+func (z *Zet) PlanImport(dir string) (*Importer, error) {
+	im := &Importer{
+		root:   dir,
+		byPath: make(map[string]*importNote),
+		byName: make(map[string]*importNote),
+		files:  make(map[string]string),
+	}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !isMarkdown(rel) {
+			// other files are only copied when a note references them
+			if _, ok := im.files[strings.ToLower(d.Name())]; !ok {
+				im.files[strings.ToLower(d.Name())] = rel
+			}
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		c, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		im.notes = append(im.notes, &importNote{
+			Src:   rel,
+			Title: importTitle(string(c), rel),
+			Time:  fi.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(im.notes, func(i, j int) bool {
+		if im.notes[i].Time.Equal(im.notes[j].Time) {
+			return im.notes[i].Src < im.notes[j].Src
+		}
+		return im.notes[i].Time.Before(im.notes[j].Time)
+	})
+
+	taken := make(map[string]bool)
+	for _, n := range im.notes {
+		n.Id, err = freeId(n.Time, taken)
+		if err != nil {
+			return nil, err
+		}
+		taken[n.Id] = true
+		im.byPath[strings.ToLower(strings.TrimSuffix(n.Src, path.Ext(n.Src)))] = n
+		for _, k := range []string{importName(n.Src), n.Title} {
+			if _, ok := im.byName[strings.ToLower(k)]; !ok {
+				im.byName[strings.ToLower(k)] = n
+			}
+		}
+	}
+	return im, nil
+}
+
+// freeId returns the id for t, advancing t by the id resolution while the id
+// is taken by an existing zet or another file in the import.
+func freeId(t time.Time, taken map[string]bool) (string, error) {
+	for i := 0; i < maxIdAttempts; i++ {
+		id, err := NewId(t)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(filepath.Join(Repo, id)); !taken[id] && errors.Is(err, fs.ErrNotExist) {
+			return id, nil
+		}
+		t = t.Add(idResolution())
+	}
+	return "", fmt.Errorf("no free zet id found after %d attempts", maxIdAttempts)
+}
+
+// Import writes every planned zet into the repo, converting tags to a zet
+// tags line, rewriting links between the files as links between zets and
+// copying referenced images and other files alongside. If any file fails the
+// zets created so far are removed.
+func (im *Importer) Import() (err error) {
+	var created []string
+	defer func() {
+		if err != nil {
+			for _, d := range created {
+				_ = os.RemoveAll(d)
+			}
+		}
+	}()
+	err = mkdir(Repo)
+	if err != nil {
+		return err
+	}
+	for _, n := range im.notes {
+		dir := filepath.Join(Repo, n.Id)
+		err = os.Mkdir(dir, 0755)
+		if err != nil {
+			return err
+		}
+		created = append(created, dir)
+
+		c, err := os.ReadFile(filepath.Join(im.root, filepath.FromSlash(n.Src)))
+		if err != nil {
+			return err
+		}
+		readme, files := im.convert(n, string(c))
+		for name, src := range files {
+			err = copyFile(filepath.Join(im.root, filepath.FromSlash(src)), filepath.Join(dir, name))
+			if err != nil {
+				return err
+			}
+		}
+		err = os.WriteFile(filepath.Join(dir, "README.md"), []byte(readme), 0664)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convert returns the README.md for n from the file content c, along with the
+// files to copy into the zet keyed by the name they are stored under.
+func (im *Importer) convert(n *importNote, c string) (string, map[string]string) {
+	_, body := splitFrontMatter(c)
+	tags := frontMatterTags(c)
+	tags = append(tags, parseTags(body)...)
+	body = stripTagLines(body)
+
+	files := make(map[string]string)
+	lines := strings.Split(body, "\n")
+	var out []string
+	fenced, h1 := false, false
+	for _, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "```") {
+			fenced = !fenced
+		}
+		if fenced {
+			out = append(out, l)
+			continue
+		}
+		if strings.HasPrefix(l, "# ") {
+			h1 = true
+		}
+		for _, m := range importTagRegex.FindAllStringSubmatch(l, -1) {
+			tags = append(tags, m[2])
+		}
+		// lines holding nothing but tags move to the tags line entirely
+		if strings.TrimSpace(importTagRegex.ReplaceAllString(l, "")) == "" && strings.TrimSpace(l) != "" {
+			continue
+		}
+		out = append(out, im.rewriteLinks(n, l, files))
+	}
+	body = strings.TrimSpace(strings.Join(out, "\n"))
+	if !h1 {
+		body = "# " + n.Title + "\n\n" + body
+	}
+	if t := formatTags(importTags(tags)); t != "" {
+		body += "\n\n" + t
+	}
+	return body + "\n", files
+}
+
+// rewriteLinks rewrites the wiki and Markdown links on line which point at
+// other imported files as zet links, and those pointing at other local files
+// as references to a copy within the zet, recorded in files. Links which
+// cannot be resolved are left as they are.
+func (im *Importer) rewriteLinks(n *importNote, line string, files map[string]string) string {
+	line = importWikiRegex.ReplaceAllStringFunc(line, func(s string) string {
+		m := importWikiRegex.FindStringSubmatch(s)
+		target, text := strings.TrimSpace(m[2]), strings.TrimSpace(m[3])
+		if t, ok := im.lookupNote(n.Src, target); ok && (m[1] == "" || isMarkdown(target) || path.Ext(target) == "") {
+			if text == "" {
+				text = t.Title
+			}
+			return zetLink(text, t.Id)
+		}
+		if name, ok := im.attach(n.Src, target, files); ok {
+			return attachmentRef(name)
+		}
+		return s
+	})
+	return importLinkRegex.ReplaceAllStringFunc(line, func(s string) string {
+		m := importLinkRegex.FindStringSubmatch(s)
+		target := m[3]
+		if zetLinkRegex.MatchString(s) || strings.HasPrefix(target, "#") || strings.Contains(target, ":") {
+			return s
+		}
+		if u, err := url.PathUnescape(target); err == nil {
+			target = u
+		}
+		target, _, _ = strings.Cut(target, "#")
+		if isMarkdown(target) {
+			if t, ok := im.lookupNote(n.Src, target); ok {
+				return zetLink(m[2], t.Id)
+			}
+			return s
+		}
+		if name, ok := im.attach(n.Src, target, files); ok {
+			return fmt.Sprintf("%s[%s](%s)", m[1], m[2], url.PathEscape(name))
+		}
+		return s
+	})
+}
+
+// lookupNote resolves target, as linked from the file src, to an imported
+// file. Paths relative to src and to the import root are tried before
+// falling back to matching the file name or title as Obsidian does.
+func (im *Importer) lookupNote(src, target string) (*importNote, bool) {
+	if isMarkdown(target) {
+		target = strings.TrimSuffix(target, path.Ext(target))
+	}
+	for _, p := range []string{path.Join(path.Dir(src), target), path.Clean(target)} {
+		if n, ok := im.byPath[strings.ToLower(p)]; ok {
+			return n, true
+		}
+	}
+	n, ok := im.byName[strings.ToLower(importName(target))]
+	return n, ok
+}
+
+// attach resolves target, as referenced from the file src, to a file within
+// the import root and records it in files under a name unique to the zet. It
+// returns the name the file will be stored under.
+func (im *Importer) attach(src, target string, files map[string]string) (string, bool) {
+	var rel string
+	for _, p := range []string{path.Join(path.Dir(src), target), path.Clean(target)} {
+		if strings.HasPrefix(p, "../") {
+			continue
+		}
+		if fi, err := os.Stat(filepath.Join(im.root, filepath.FromSlash(p))); err == nil && fi.Mode().IsRegular() {
+			rel = p
+			break
+		}
+	}
+	if rel == "" {
+		// Obsidian resolves embeds by name from its attachments folder
+		f, ok := im.files[strings.ToLower(path.Base(target))]
+		if !ok {
+			return "", false
+		}
+		rel = f
+	}
+	name := path.Base(rel)
+	for i := 1; ; i++ {
+		if s, ok := files[name]; !ok || s == rel {
+			break
+		}
+		ext := path.Ext(rel)
+		name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path.Base(rel), ext), i, ext)
+	}
+	files[name] = rel
+	return name, true
+}
+
+// importTitle returns the title for an imported file: its first h1, then a
+// front matter title and finally the file name.
+func importTitle(c, src string) string {
+	fm, body := splitFrontMatter(c)
+	for _, l := range strings.Split(body, "\n") {
+		if strings.HasPrefix(l, "# ") {
+			return strings.TrimSpace(strings.TrimPrefix(l, "# "))
+		}
+	}
+	if t := fm["title"]; t != "" {
+		return t
+	}
+	return importName(src)
+}
+
+// importName returns the base name of p without its extension or the id
+// Notion appends to exported file names.
+func importName(p string) string {
+	name := path.Base(p)
+	if isMarkdown(name) {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	return notionIdRegex.ReplaceAllString(name, "")
+}
+
+// frontMatterTags returns the tags listed in the front matter of c, written
+// either inline as `tags: [a, b]` or `tags: a b` or as a YAML list.
+func frontMatterTags(c string) []string {
+	fm, _ := splitFrontMatter(c)
+	if fm == nil {
+		return nil
+	}
+	var tags []string
+	list := false
+	for _, l := range strings.Split(c, "\n")[1:] {
+		t := strings.TrimSpace(l)
+		if t == frontMatterFence {
+			break
+		}
+		if list && strings.HasPrefix(t, "- ") {
+			tags = append(tags, strings.TrimPrefix(t, "- "))
+			continue
+		}
+		list = false
+		k, v, ok := strings.Cut(t, ":")
+		if !ok || (k != "tags" && k != "tag") {
+			continue
+		}
+		v = strings.Trim(strings.TrimSpace(v), "[]")
+		if v == "" {
+			list = true
+			continue
+		}
+		tags = append(tags, strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' '
+		})...)
+	}
+	return tags
+}
+
+// importTags normalises tags into the form zet uses, with nested tags joined
+// by '-', and removes duplicates.
+func importTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = strings.Trim(strings.TrimSpace(t), `"'#`)
+		t = strings.Trim(strings.ReplaceAll(t, "/", "-"), "-")
+		if t == "" || seen[t] || !tagRegex.MatchString("#"+t) {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// isMarkdown reports whether p names a Markdown file.
+func isMarkdown(p string) bool {
+	ext := strings.ToLower(path.Ext(p))
+	return ext == ".md" || ext == ".markdown"
+}
@@ -4,13 +4,24 @@ package term
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"github.com/danielmichaels/zet-cmd/internal/esc"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	xterm "golang.org/x/term"
 )
 
+// WaitDelay is how long a command is given to exit after being interrupted
+// by the cancellation of its context before it is killed.
+var WaitDelay = 5 * time.Second
+
 func init() {
 	SetInteractive(DetectInteractive())
 }
@@ -90,12 +101,59 @@ func DetectInteractive() bool {
 	return false
 }
 
+// stdin buffers os.Stdin across calls to Read. A reader per call would
+// discard whatever it buffered beyond the first line, losing the answers to
+// later prompts when input is piped. It is rebuilt if os.Stdin is replaced.
+var stdin struct {
+	f *os.File
+	r *bufio.Reader
+}
+
 // Read reads a single line of input and chomps the \r?\n. Also see
 // ReadHidden.
 func Read() string {
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	return scanner.Text()
+	if stdin.f != os.Stdin {
+		stdin.f, stdin.r = os.Stdin, bufio.NewReader(os.Stdin)
+	}
+	line, _ := stdin.r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
+
+// PromptHidden is Prompt but reads the response with ReadHidden so that it
+// is not echoed, for passphrases and the like.
+func PromptHidden(form string, args ...any) string {
+	if IsInteractive() {
+		fmt.Printf(form, args...)
+	}
+	s := ReadHidden()
+	if IsInteractive() {
+		fmt.Println()
+	}
+	return s
+}
+
+// ReadHidden reads a single line of input without echoing it when stdin is
+// a terminal, otherwise it is identical to Read.
+func ReadHidden() string {
+	fd := int(os.Stdin.Fd())
+	if !xterm.IsTerminal(fd) {
+		return Read()
+	}
+	b, err := xterm.ReadPassword(fd)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Size returns the width and height of the terminal connected to stdout. The
+// final result is false when stdout is not a terminal.
+func Size() (int, int, bool) {
+	w, h, err := xterm.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 0, 0, false
+	}
+	return w, h, true
 }
 
 // SetInteractive forces the interactive internal state affecting output
@@ -242,24 +300,179 @@ func Exec(args ...string) error {
 	cmd.Stdout = os.Stdout
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	return traced(cmd.Dir, args, cmd.Run)
 }
 
-// Out returns the standard output of the executed command as
-// a string. Errors are logged but not returned.
-func Out(args ...string) string {
+// ExecEnv is Exec with env added to the environment of the command, as when
+// handing over to a plugin.
+func ExecEnv(env []string, args ...string) error {
 	if len(args) == 0 {
-		log.Println("missing name of executable")
-		return ""
+		return fmt.Errorf("missing name of executable")
 	}
 	path, err := exec.LookPath(args[0])
 	if err != nil {
-		log.Println(err)
-		return ""
+		return err
+	}
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	return traced(cmd.Dir, args, cmd.Run)
+}
+
+// ExecIn is Exec with the command run from dir rather than the current
+// directory.
+func ExecIn(dir string, args ...string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing name of executable")
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	return traced(cmd.Dir, args, cmd.Run)
+}
+
+// Pipe is Exec with input written to the stdin of the command rather than
+// connecting that of the calling program, as when handing output to a pager.
+func Pipe(input string, args ...string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing name of executable")
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stderr = os.Stderr
+	return traced(cmd.Dir, args, cmd.Run)
+}
+
+// PipeContext is Pipe bound to ctx, run from dir with env added to the
+// environment of the command, as when running user hooks. A command which
+// fails returns a CmdError.
+func PipeContext(ctx context.Context, dir string, env []string, input string, args ...string) error {
+	cmd, err := command(ctx, dir, args)
+	if err != nil {
+		return err
+	}
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stderr = os.Stderr
+	return cmdErr(ctx, args, traced(cmd.Dir, args, cmd.Run), "")
+}
+
+// CmdError is returned by ExecContextEnv and OutContext when a command fails. It
+// carries whatever the command wrote to stderr so that the real reason for a
+// failure, such as a rejected git push, reaches the user.
+type CmdError struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *CmdError) Error() string {
+	msg := fmt.Sprintf("%s: %v", strings.Join(e.Args, " "), e.Err)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *CmdError) Unwrap() error { return e.Err }
+
+// command builds an exec.Cmd bound to ctx to be run from dir, or the current
+// directory when dir is empty. Cancelling ctx sends the process an interrupt,
+// as a terminal would for Ctrl-C, and only kills it if it is still running
+// after WaitDelay.
+func command(ctx context.Context, dir string, args []string) (*exec.Cmd, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing name of executable")
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, path, args[1:]...)
+	cmd.Dir = dir
+	if runtime.GOOS != "windows" {
+		cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	}
+	cmd.WaitDelay = WaitDelay
+	return cmd, nil
+}
+
+// ExecContextEnv is ExecIn bound to ctx with env added to the environment of
+// the command. Stderr is captured rather than connected to that of the calling
+// program and is included in the returned error. If ctx is done before the
+// command exits the context error is returned. Programs prompting for
+// credentials, such as ssh, do so on the tty and are unaffected.
+func ExecContextEnv(ctx context.Context, dir string, env []string, args ...string) error {
+	cmd, err := command(ctx, dir, args)
+	if err != nil {
+		return err
+	}
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stdout = os.Stdout
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = &stderr
+	return cmdErr(ctx, args, traced(cmd.Dir, args, cmd.Run), stderr.String())
+}
+
+// OutContext returns the standard output of the command run from dir as a
+// string, bound to ctx like ExecContextEnv. A command which fails returns a
+// CmdError including the captured stderr.
+func OutContext(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd, err := command(ctx, dir, args)
+	if err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	var out []byte
+	err = traced(cmd.Dir, args, func() error {
+		out, err = cmd.Output()
+		return err
+	})
+	return string(out), cmdErr(ctx, args, err, stderr.String())
+}
+
+// traced runs fn, which runs the command args from dir, logging the command
+// and how long it took at debug level so it shows with zet -v.
+func traced(dir string, args []string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	attrs := []any{"args", args, "duration", time.Since(start)}
+	if dir != "" {
+		attrs = append(attrs, "dir", dir)
 	}
-	out, err := exec.Command(path, args[1:]...).Output()
 	if err != nil {
-		log.Println(err)
+		attrs = append(attrs, "err", err)
+	}
+	slog.Debug("exec", attrs...)
+	return err
+}
+
+// cmdErr wraps a failed command in a CmdError, preferring the context error
+// when the command was stopped because ctx was done.
+func cmdErr(ctx context.Context, args []string, err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		err = ctx.Err()
 	}
-	return string(out)
+	return &CmdError{Args: args, Stderr: strings.TrimSpace(stderr), Err: err}
 }
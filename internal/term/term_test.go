@@ -0,0 +1,101 @@
+package term
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func skipWithoutSh(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are shell scripts")
+	}
+}
+
+func TestCmdErrorStderr(t *testing.T) {
+	skipWithoutSh(t)
+	args := []string{"sh", "-c", "echo out; echo x >&2; exit 2"}
+	run := map[string]func() error{
+		"OutContext": func() error {
+			out, err := OutContext(context.Background(), "", args...)
+			if out != "out\n" {
+				t.Errorf("OutContext stdout = %q, want %q", out, "out\n")
+			}
+			return err
+		},
+		"ExecContextEnv": func() error {
+			return ExecContextEnv(context.Background(), "", nil, args...)
+		},
+	}
+	for name, fn := range run {
+		t.Run(name, func(t *testing.T) {
+			err := fn()
+			var ce *CmdError
+			if !errors.As(err, &ce) {
+				t.Fatalf("err = %v, want a CmdError", err)
+			}
+			if ce.Stderr != "x" {
+				t.Errorf("Stderr = %q, want %q", ce.Stderr, "x")
+			}
+			var exit *exec.ExitError
+			if !errors.As(err, &exit) || exit.ExitCode() != 2 {
+				t.Errorf("err = %v, want exit status 2", err)
+			}
+			want := "sh -c echo out; echo x >&2; exit 2: exit status 2: x"
+			if err.Error() != want {
+				t.Errorf("Error() = %q, want %q", err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestCancelled(t *testing.T) {
+	skipWithoutSh(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	_, err := OutContext(ctx, "", "sh", "-c", "sleep 5")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("took %v, want the command not to run", d)
+	}
+}
+
+func TestInterrupted(t *testing.T) {
+	skipWithoutSh(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := ExecContextEnv(ctx, "", nil, "sh", "-c", "exec sleep 5")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("took %v, want the interrupt to stop the command", d)
+	}
+}
+
+func TestWaitDelay(t *testing.T) {
+	skipWithoutSh(t)
+	delay := WaitDelay
+	t.Cleanup(func() { WaitDelay = delay })
+	WaitDelay = 100 * time.Millisecond
+
+	// the command ignores the interrupt so is only stopped by the kill
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := OutContext(ctx, "", "sh", "-c", "trap '' INT; sleep 5")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("took %v, want the command killed after WaitDelay", d)
+	}
+}
@@ -0,0 +1,180 @@
+package zet
+
+import (
+	"context"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// journalKey is the front matter entry holding the date of a journal.
+	journalKey    = "journal"
+	journalPrefix = "Journal "
+)
+
+// journalTitleRegex matches the title convention of journals created before
+// front matter was used, or written by hand.
+var journalTitleRegex = regexp.MustCompile(`^Journal (\d{4}-\d{2}-\d{2})$`)
+
+type TodayCmd struct {
+	Append string `help:"Append a timestamped bullet to today's journal without opening the editor" short:"a"`
+}
+
+func (c *TodayCmd) Run(ctx context.Context) error {
+	z := new(Zet)
+	err := z.checkRepo()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	zet, err := z.Journal(now)
+	if err != nil {
+		return err
+	}
+	z.Path = filepath.Join(Repo, zet)
+	z.Op = OpJournal
+
+	if c.Append != "" {
+		err = z.AppendJournal(zet, now, c.Append)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = z.openZetForEdit(z.Path)
+		if err != nil {
+			return err
+		}
+	}
+	err = z.scanAndCommit(ctx, z.Path)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type JournalCmd struct {
+	Week bool `help:"Render the journals from the past week together"`
+}
+
+func (c *JournalCmd) Run() error {
+	z := new(Zet)
+	err := z.checkRepo()
+	if err != nil {
+		return err
+	}
+	journals, err := z.Journals()
+	if err != nil {
+		return err
+	}
+	var dates []string
+	for d := range journals {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+
+	if !c.Week {
+		for _, d := range dates {
+			fmt.Println(journals[d], d)
+		}
+		return nil
+	}
+	from := time.Now().AddDate(0, 0, -6).Format(dateLayout)
+	var pages []string
+	for _, d := range dates {
+		if d < from {
+			continue
+		}
+		b, err := readReadme(filepath.Join(Repo, journals[d]))
+		if err != nil {
+			return err
+		}
+		_, body := splitFrontMatter(b)
+		pages = append(pages, strings.TrimSpace(body))
+	}
+	if len(pages) == 0 {
+		fmt.Println("No journals in the past week")
+		return nil
+	}
+	out, err := renderMarkdown(strings.Join(pages, "\n\n---\n\n") + "\n")
+	if err != nil {
+		return err
+	}
+	return page(out)
+}
+
+// Journals returns the id of every journal zet keyed by its date. Journals are
+// identified by their front matter, falling back to the title convention.
+func (z *Zet) Journals() (map[string]string, error) {
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return nil, err
+	}
+	journals := make(map[string]string)
+	for _, f := range files {
+		c, err := readReadme(filepath.Join(Repo, f))
+		if isLocked(err) || isMissing(f, err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		fm, _ := splitFrontMatter(c)
+		if d := fm[journalKey]; d != "" {
+			journals[d] = f
+			continue
+		}
+		if m := journalTitleRegex.FindStringSubmatch(parseTitle(c)); m != nil {
+			journals[m[1]] = f
+		}
+	}
+	return journals, nil
+}
+
+// Journal returns the id of the journal for the day of t, creating it if it
+// does not yet exist.
+func (z *Zet) Journal(t time.Time) (string, error) {
+	journals, err := z.Journals()
+	if err != nil {
+		return "", err
+	}
+	date := t.Format(dateLayout)
+	if zet, ok := journals[date]; ok {
+		return zet, nil
+	}
+	dir, err := z.CreateDir()
+	if err != nil {
+		return "", err
+	}
+	c := fmt.Sprintf("---\n%s: %s\n---\n# %s%s\n\n", journalKey, date, journalPrefix, date)
+	err = os.WriteFile(z.GetReadme(dir), []byte(c), 0664)
+	if err != nil {
+		return "", err
+	}
+	fmt.Println(term.Green + "Created journal " + date + term.Reset)
+	return filepath.Base(dir), nil
+}
+
+// AppendJournal adds text to the journal zet as a bullet prefixed with the
+// time of t.
+func (z *Zet) AppendJournal(zet string, t time.Time, text string) error {
+	p := z.GetReadme(filepath.Join(Repo, zet))
+	c, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	s := strings.TrimRight(string(c), "\n")
+	// keep bullets together as a single list
+	sep := "\n\n"
+	lines := strings.Split(s, "\n")
+	if strings.HasPrefix(lines[len(lines)-1], "- ") {
+		sep = "\n"
+	}
+	s += sep + fmt.Sprintf("- %s %s", t.Format("15:04"), text) + "\n"
+	return os.WriteFile(p, []byte(s), 0664)
+}
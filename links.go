@@ -0,0 +1,78 @@
+package zet
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// zetLinkRegex matches Markdown links to another zet such as
+// [Title](../20220424000235) or [Title](/20220424000235/). The first group is
+// the link text, the second the optional prefix and the third the zet id.
+var zetLinkRegex = regexp.MustCompile(`\[([^\]]*)\]\((\.\./|/)?(` + idPattern + `)/?\)`)
+
+// wikiLinkRegex matches [[Title]] style links used by other note apps.
+var wikiLinkRegex = regexp.MustCompile(`\[\[([^\]]+)\]\]`)
+
+// Link is a reference from one zet to another.
+type Link struct {
+	Text string
+	Id   string
+}
+
+// FindLinks returns every link to another zet within content.
+func FindLinks(content string) []Link {
+	var links []Link
+	for _, m := range zetLinkRegex.FindAllStringSubmatch(content, -1) {
+		links = append(links, Link{Text: m[1], Id: m[3]})
+	}
+	return links
+}
+
+// zetLink returns the canonical Markdown link to the zet with id.
+func zetLink(title, id string) string {
+	return fmt.Sprintf("[%s](../%s)", title, id)
+}
+
+// retitleLinks rewrites any link to id which carries oldTitle as its text to
+// use newTitle instead. Wiki links using the old title are updated too. Links
+// with custom text are left untouched.
+func retitleLinks(content, id, oldTitle, newTitle string) string {
+	content = zetLinkRegex.ReplaceAllStringFunc(content, func(s string) string {
+		m := zetLinkRegex.FindStringSubmatch(s)
+		if m[3] != id || strings.TrimSpace(m[1]) != oldTitle {
+			return s
+		}
+		return fmt.Sprintf("[%s](%s%s)", newTitle, m[2], m[3])
+	})
+	return strings.ReplaceAll(content, "[["+oldTitle+"]]", "[["+newTitle+"]]")
+}
+
+// unlinkZet replaces every link to id with its text, such as links left within
+// a zet to itself.
+func unlinkZet(content, id string) string {
+	return zetLinkRegex.ReplaceAllStringFunc(content, func(s string) string {
+		m := zetLinkRegex.FindStringSubmatch(s)
+		if m[3] != id {
+			return s
+		}
+		return m[1]
+	})
+}
+
+// retargetLinks points every link to the zet from at the zet to instead. Link
+// text matching fromTitle is replaced with toTitle as the old title would no
+// longer describe the destination.
+func retargetLinks(content, from, to, fromTitle, toTitle string) string {
+	return zetLinkRegex.ReplaceAllStringFunc(content, func(s string) string {
+		m := zetLinkRegex.FindStringSubmatch(s)
+		if m[3] != from {
+			return s
+		}
+		text := m[1]
+		if strings.TrimSpace(text) == fromTitle {
+			text = toTitle
+		}
+		return fmt.Sprintf("[%s](%s%s)", text, m[2], to)
+	})
+}
@@ -0,0 +1,23 @@
+package zet
+
+import (
+	"io"
+	"log/slog"
+)
+
+// SetupLogging sets the default slog logger used throughout zet. Verbose
+// traces commands run, directory changes, cache lookups and git results to w
+// as text or JSON. Otherwise nothing is logged so that only command output
+// and errors reach the terminal.
+func SetupLogging(w io.Writer, verbose bool, format string) {
+	if !verbose {
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+		return
+	}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var h slog.Handler = slog.NewTextHandler(w, opts)
+	if format == "json" {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(h))
+}
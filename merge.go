@@ -0,0 +1,193 @@
+package zet
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// demoteRegex matches headings which can be demoted by a level.
+var demoteRegex = regexp.MustCompile(`^#{1,5} `)
+
+type MergeCmd struct {
+	Into string `arg:"" predictor:"ids" help:"Isosec of the zet to merge into"`
+	From string `arg:"" predictor:"ids" help:"Isosec of the zet merged and left behind as a redirect stub"`
+}
+
+func (c *MergeCmd) Run(ctx context.Context) error {
+	z := new(Zet)
+	into, err := z.GetZet(c.Into)
+	if err != nil {
+		return err
+	}
+	from, err := z.GetZet(c.From)
+	if err != nil {
+		return err
+	}
+	if into == from {
+		return fmt.Errorf("cannot merge %s into itself", into)
+	}
+	// capture both histories before the merge rewrites them
+	intoLog, err := gitOut(ctx, "log", "--format=%h %s", "--", into)
+	if err != nil {
+		return err
+	}
+	fromLog, err := gitOut(ctx, "log", "--format=%h %s", "--", from)
+	if err != nil {
+		return err
+	}
+
+	intoTitle, fromTitle, updated, err := z.Merge(into, from)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Merged %s %q into %s %q\n", from, fromTitle, into, intoTitle)
+	for _, u := range updated {
+		fmt.Printf("Updated links in %s\n", u)
+	}
+
+	z.Path = filepath.Join(Repo, into)
+	z.Ids = []string{into, from}
+	for _, u := range updated {
+		if u != into {
+			z.Ids = append(z.Ids, u)
+		}
+	}
+	z.Op = OpMerge
+	z.Title = fmt.Sprintf("%s into %s\n\n%s history:\n%s\n%s history:\n%s",
+		fromTitle, intoTitle, into, intoLog, from, fromLog)
+	err = z.scanAndCommit(ctx, into)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Merge appends the content of the zet from to the zet into under a heading
+// of its title, combining their tags and moving any attachments across. The
+// from zet is replaced with a stub redirecting to into, keeping its tags, and
+// every link to it is rewritten to point at into. Links between the two zets
+// within into are left as plain text. The titles of both zets are returned
+// along with the ids of the zets whose links were updated.
+func (z *Zet) Merge(into, from string) (string, string, []string, error) {
+	for _, zet := range []string{into, from} {
+		if err := requirePublic(zet, "merged"); err != nil {
+			return "", "", nil, err
+		}
+	}
+	intoPath := z.GetReadme(filepath.Join(Repo, into))
+	fromPath := z.GetReadme(filepath.Join(Repo, from))
+	ic, err := os.ReadFile(intoPath)
+	if err != nil {
+		return "", "", nil, err
+	}
+	fc, err := os.ReadFile(fromPath)
+	if err != nil {
+		return "", "", nil, err
+	}
+	intoTitle := parseTitle(string(ic))
+	fromTitle := parseTitle(string(fc))
+
+	// check attachments first so a name clash leaves both zets untouched
+	entries, err := os.ReadDir(filepath.Join(Repo, from))
+	if err != nil {
+		return "", "", nil, err
+	}
+	var attachments []string
+	for _, e := range entries {
+		if e.Name() == "README.md" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(Repo, into, e.Name())); err == nil {
+			return "", "", nil, fmt.Errorf("%q exists in both %s and %s", e.Name(), into, from)
+		}
+		attachments = append(attachments, e.Name())
+	}
+	for _, a := range attachments {
+		err = os.Rename(filepath.Join(Repo, from, a), filepath.Join(Repo, into, a))
+		if err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	_, body := splitFrontMatter(string(fc))
+	// drop the h1 and demote the remaining headings so they nest beneath the
+	// section heading the content is merged under
+	var kept []string
+	h1, fenced := false, false
+	for _, l := range strings.Split(body, "\n") {
+		if strings.HasPrefix(l, "```") {
+			fenced = !fenced
+		}
+		switch {
+		case fenced:
+		case !h1 && strings.HasPrefix(l, "# "):
+			h1 = true
+			continue
+		case demoteRegex.MatchString(l):
+			l = "#" + l
+		}
+		kept = append(kept, l)
+	}
+	body = strings.TrimSpace(stripTagLines(strings.Join(kept, "\n")))
+
+	tags := parseTags(string(ic))
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		seen[t] = true
+	}
+	for _, t := range parseTags(string(fc)) {
+		if !seen[t] {
+			tags = append(tags, t)
+		}
+	}
+	merged := fmt.Sprintf("%s\n\n## %s\n\n%s\n", stripTagLines(string(ic)), fromTitle, body)
+	if len(tags) > 0 {
+		merged += "\n" + formatTags(tags) + "\n"
+	}
+	err = os.WriteFile(intoPath, []byte(merged), 0664)
+	if err != nil {
+		return "", "", nil, err
+	}
+	stub := fmt.Sprintf("# %s\n\nMerged into %s\n", fromTitle, zetLink(intoTitle, into))
+	if t := parseTags(string(fc)); len(t) > 0 {
+		stub += "\n" + formatTags(t) + "\n"
+	}
+	err = os.WriteFile(fromPath, []byte(stub), 0664)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return "", "", nil, err
+	}
+	var updated []string
+	for _, f := range files {
+		if f == from || isPrivate(filepath.Join(Repo, f)) {
+			continue
+		}
+		p := z.GetReadme(filepath.Join(Repo, f))
+		c, err := os.ReadFile(p)
+		if err != nil {
+			return "", "", nil, err
+		}
+		n := retargetLinks(string(c), from, into, fromTitle, intoTitle)
+		if f == into {
+			// links between the two zets would now lead back to into itself
+			n = unlinkZet(unlinkZet(string(c), from), into)
+		}
+		if n == string(c) {
+			continue
+		}
+		err = os.WriteFile(p, []byte(n), 0664)
+		if err != nil {
+			return "", "", nil, err
+		}
+		updated = append(updated, f)
+	}
+	return intoTitle, fromTitle, updated, nil
+}
@@ -0,0 +1,40 @@
+package zet
+
+import (
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"os/exec"
+	"strings"
+)
+
+// defaultPager is used when $PAGER is not set. -R passes the colours of
+// rendered zets through rather than escaping them.
+const defaultPager = "less -R"
+
+// NoPager disables paging, set from the --no-pager flag or ZET_NO_PAGER.
+var NoPager bool
+
+// page prints out through the pager when stdout is interactive and out is
+// too long to fit the terminal. Otherwise, or when the pager cannot be found,
+// out is printed directly.
+func page(out string) error {
+	if NoPager || !term.IsInteractive() {
+		fmt.Print(out)
+		return nil
+	}
+	if _, h, ok := term.Size(); ok && strings.Count(out, "\n") < h {
+		fmt.Print(out)
+		return nil
+	}
+	pager := strings.Fields(Pager)
+	if len(pager) == 0 {
+		pager = strings.Fields(defaultPager)
+	}
+	err := term.Pipe(out, pager...)
+	if errors.Is(err, exec.ErrNotFound) {
+		fmt.Print(out)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package zet
+
+import (
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pluginPrefix names the executables on PATH run as zet subcommands, so
+// `zet foo` runs `zet-foo` as git and kubectl do.
+const pluginPrefix = "zet-"
+
+type PluginsCmd struct {
+	List PluginsListCmd `cmd:"" help:"List the zet-* plugins found on PATH"`
+}
+
+type PluginsListCmd struct{}
+
+func (c *PluginsListCmd) Run() error {
+	plugins := Plugins()
+	if len(plugins) == 0 {
+		fmt.Println("No plugins found on PATH")
+		return nil
+	}
+	for _, p := range plugins {
+		fmt.Printf("%s %s\n", p.Name, p.Path)
+	}
+	return nil
+}
+
+// Plugin is an executable on PATH run as a zet subcommand.
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// Plugins returns the plugins on PATH ordered by name. When a plugin is found
+// in several directories the first wins, as it would when run.
+func Plugins() []Plugin {
+	var plugins []Plugin
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), pluginPrefix)
+			if !ok || e.IsDir() {
+				continue
+			}
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			path := filepath.Join(dir, e.Name())
+			if name == "" || seen[name] || !isExecutable(path) {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// isExecutable reports whether path is a file that can be run.
+func isExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil || fi.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || fi.Mode()&0111 != 0
+}
+
+// LookPlugin returns the path of the plugin called name.
+func LookPlugin(name string) (string, error) {
+	return exec.LookPath(pluginPrefix + name)
+}
+
+// PluginExit is returned when a plugin exits unsuccessfully. The plugin will
+// have reported its own error so only the exit code is passed on.
+type PluginExit struct {
+	Name string
+	Code int
+}
+
+func (e *PluginExit) Error() string {
+	return fmt.Sprintf("plugin %s exited with status %d", e.Name, e.Code)
+}
+
+func (e *PluginExit) ExitCode() int { return e.Code }
+
+// RunPlugin runs the plugin at path with args. The resolved repo is passed as
+// ZET_REPO, empty when none is set, and g under the same ZET_* variables zet
+// reads its flags from, so a plugin calling back into zet keeps the same
+// settings.
+func RunPlugin(path string, args []string, g Globals) error {
+	env := []string{
+		"ZET_REPO=" + Repo,
+		"ZETDIR=" + Repo,
+		"ZET_VERBOSE=" + strconv.FormatBool(g.Verbose),
+		"ZET_LOG_FORMAT=" + g.LogFormat,
+		"ZET_GIT_TIMEOUT=" + g.GitTimeout.String(),
+		"ZET_NO_PAGER=" + strconv.FormatBool(g.NoPager),
+		"ZET_STYLE=" + g.Style,
+		"ZET_WIDTH=" + strconv.Itoa(g.Width),
+		"ZET_RAW=" + strconv.FormatBool(g.Raw),
+		"ZET_COMMIT_TEMPLATE=" + g.CommitTemplate,
+		"ZET_CONVENTIONAL_COMMITS=" + strconv.FormatBool(g.ConventionalCommits),
+	}
+	err := term.ExecEnv(env, append([]string{path}, args...)...)
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return &PluginExit{Name: filepath.Base(path), Code: exit.ExitCode()}
+	}
+	return err
+}
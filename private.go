@@ -0,0 +1,329 @@
+package zet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// privateReadme replaces README.md in private zets and holds its
+	// encrypted content.
+	privateReadme = "README.md.enc"
+	// privateMagic prefixes every encrypted README so the format can evolve.
+	privateMagic = "zet-enc-v1\n"
+	// lockedTitle is listed in place of the title of a private zet when no
+	// passphrase is available.
+	lockedTitle = "🔒 locked"
+	// privateTitle replaces the title of a private zet in commit messages and
+	// user hooks, which would otherwise carry it out of the encrypted README.
+	privateTitle = "private zet"
+	// saltFile holds the random salt, shared by every private zet in the
+	// repo, used to derive the encryption key from the passphrase.
+	saltFile = "salt"
+	saltSize = 16
+	// kdfIterations follows the OWASP recommendation for PBKDF2-SHA256.
+	kdfIterations = 600000
+)
+
+var (
+	// ErrLocked is returned when reading a private zet without a passphrase.
+	ErrLocked = errors.New("zet is private and no passphrase is available")
+	// ErrDecrypt is returned when a private zet cannot be decrypted, most
+	// likely because the passphrase is wrong.
+	ErrDecrypt = errors.New("incorrect passphrase or corrupted private zet")
+)
+
+// Passphrase for private zets, read from ZET_PASSPHRASE. When empty the user
+// is prompted the first time a private zet is opened for viewing or editing.
+var Passphrase = os.Getenv("ZET_PASSPHRASE")
+
+// isPrivate reports whether the zet directory at path holds a private zet.
+func isPrivate(path string) bool {
+	_, err := os.Stat(filepath.Join(path, privateReadme))
+	return err == nil
+}
+
+// isLocked reports whether err means a private zet could not be read, for
+// want of a passphrase or because it is the wrong one. Listings skip or show
+// such zets as locked so that one cannot stop the rest being listed.
+func isLocked(err error) bool {
+	return errors.Is(err, ErrLocked) || errors.Is(err, ErrDecrypt)
+}
+
+// publicTitle returns title, or privateTitle when the zet with id is private.
+func publicTitle(id, title string) string {
+	if id != "" && isPrivate(filepath.Join(Repo, id)) {
+		return privateTitle
+	}
+	return title
+}
+
+// readReadme returns the content of the README.md within the zet directory at
+// path, decrypting it in memory for private zets. Private zets return
+// ErrLocked when no passphrase is set rather than prompting, which keeps
+// listing commands non-interactive, see isLocked.
+func readReadme(path string) (string, error) {
+	if !isPrivate(path) {
+		c, err := os.ReadFile(filepath.Join(path, "README.md"))
+		return string(c), err
+	}
+	key, err := privateKey(false)
+	if err != nil {
+		return "", err
+	}
+	c, err := os.ReadFile(filepath.Join(path, privateReadme))
+	if err != nil {
+		return "", err
+	}
+	p, err := decrypt(c, key)
+	if err != nil {
+		return "", err
+	}
+	return string(p), nil
+}
+
+// unlock ensures a passphrase is available, prompting for one if needed. When
+// confirm is true the passphrase must be entered twice, as when creating a
+// private zet.
+func unlock(confirm bool) error {
+	if Passphrase != "" {
+		return nil
+	}
+	p := term.PromptHidden("Passphrase: ")
+	if p == "" {
+		return ErrLocked
+	}
+	if confirm && term.PromptHidden("Confirm passphrase: ") != p {
+		return errors.New("passphrases do not match")
+	}
+	Passphrase = p
+	return nil
+}
+
+// createPrivate creates a private zet, writing it in the editor from a
+// temporary file so that the plaintext never touches the repo.
+func (z *Zet) createPrivate() error {
+	err := unlock(true)
+	if err != nil {
+		return err
+	}
+	dir, err := z.CreateDir()
+	if err != nil {
+		return err
+	}
+	z.Path = dir
+	return editPlaintext(dir, []byte(fmt.Sprintf("# %s\n\n", z.Title)))
+}
+
+// readZet is readReadme for commands opening a single zet, prompting for the
+// passphrase when the zet is private and none is set.
+func readZet(path string) (string, error) {
+	if isPrivate(path) {
+		if err := unlock(false); err != nil {
+			return "", err
+		}
+	}
+	return readReadme(path)
+}
+
+// writePrivate encrypts content into the private README of the zet directory
+// at path.
+func writePrivate(path string, content []byte) error {
+	key, err := privateKey(true)
+	if err != nil {
+		return err
+	}
+	c, err := encrypt(content, key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(path, privateReadme), c, 0664)
+}
+
+// editPrivate decrypts the private zet at path into a temporary file outside
+// the repo, opens it in the editor and encrypts the result back. The plaintext
+// is wiped afterwards whether or not editing succeeded.
+func editPrivate(path string) error {
+	c, err := readZet(path)
+	if err != nil {
+		return err
+	}
+	return editPlaintext(path, []byte(c))
+}
+
+// editPlaintext opens content in the editor from a temporary file outside the
+// repo and encrypts the result into the private zet at path.
+func editPlaintext(path string, content []byte) error {
+	f, err := plaintextFile(content)
+	if err != nil {
+		return err
+	}
+	defer wipe(f)
+	err = term.Exec(Editor, f)
+	if err != nil {
+		return err
+	}
+	n, err := os.ReadFile(f)
+	if err != nil {
+		return err
+	}
+	return writePrivate(path, n)
+}
+
+// plaintextFile writes content to a new temporary file readable only by the
+// user, preferring memory backed storage where the system has it.
+func plaintextFile(content []byte) (string, error) {
+	dir := ""
+	if fi, err := os.Stat("/dev/shm"); err == nil && fi.IsDir() {
+		dir = "/dev/shm"
+	}
+	f, err := os.CreateTemp(dir, "zet-*.md")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(content); err != nil {
+		_ = f.Close()
+		wipe(f.Name())
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// wipe overwrites the file at path with zeros before removing it.
+func wipe(path string) {
+	if fi, err := os.Stat(path); err == nil {
+		_ = os.WriteFile(path, make([]byte, fi.Size()), 0600)
+	}
+	_ = os.Remove(path)
+}
+
+// checkPlaintext returns an error if any private zet within the repo also
+// holds a plaintext README.md, which would otherwise be committed.
+func (z *Zet) checkPlaintext() error {
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		dir := filepath.Join(Repo, f)
+		if !isPrivate(dir) {
+			continue
+		}
+		if _, err := os.Stat(z.GetReadme(dir)); err == nil {
+			return fmt.Errorf("private zet %s has a plaintext README.md, refusing to commit", f)
+		}
+	}
+	return nil
+}
+
+// requirePublic returns an error naming op if zet is private. Operations which
+// rewrite README.md in place are not supported for private zets as they would
+// write plaintext into the repo.
+func requirePublic(zet, op string) error {
+	if isPrivate(filepath.Join(Repo, zet)) {
+		return fmt.Errorf("%s is private and cannot be %s", zet, op)
+	}
+	return nil
+}
+
+// privateKey returns the key for private zets, derived from Passphrase and
+// the repo salt. Derivation is deliberately slow so the key is cached for the
+// life of the process. The salt is created when create is true and it does
+// not yet exist, as when the first private zet is made.
+func privateKey(create bool) ([]byte, error) {
+	if Passphrase == "" {
+		return nil, ErrLocked
+	}
+	keyCache.Lock()
+	defer keyCache.Unlock()
+	if keyCache.key != nil && keyCache.passphrase == Passphrase && keyCache.repo == Repo {
+		slog.Debug("key cache", "hit", true)
+		return keyCache.key, nil
+	}
+	slog.Debug("key cache", "hit", false)
+	p := filepath.Join(Repo, zetConfigDir, saltFile)
+	salt, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) && create {
+		salt = make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		if err := mkdir(filepath.Dir(p)); err != nil {
+			return nil, err
+		}
+		err = os.WriteFile(p, salt, 0664)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading private zet salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, Passphrase, salt, kdfIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	keyCache.key, keyCache.passphrase, keyCache.repo = key, Passphrase, Repo
+	return key, nil
+}
+
+// keyCache holds the most recently derived private key along with the
+// passphrase and repo, whose salt it was derived with. It is shared by the
+// goroutines scanning zets.
+var keyCache struct {
+	sync.Mutex
+	key        []byte
+	passphrase string
+	repo       string
+}
+
+// encrypt seals plaintext with AES-256-GCM under key using a random nonce.
+func encrypt(plaintext, key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append([]byte(privateMagic), nonce...)
+	return aead.Seal(out, nonce, plaintext, []byte(privateMagic)), nil
+}
+
+// decrypt opens data sealed by encrypt.
+func decrypt(data, key []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(privateMagic)) {
+		return nil, ErrDecrypt
+	}
+	data = data[len(privateMagic):]
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	p, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(privateMagic))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return p, nil
+}
+
+// newAEAD returns an AES-GCM cipher using key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,53 @@
+package zet
+
+import (
+	"fmt"
+	"github.com/charmbracelet/glamour"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+)
+
+// Rendering options, set from the --style, --width and --raw flags or their
+// ZET_STYLE, ZET_WIDTH and ZET_RAW environment variables.
+var (
+	// Style is "auto", a glamour style such as "dark", "light" or "notty", or
+	// the path to a custom JSON stylesheet.
+	Style = "auto"
+	// Width is the column zets are wrapped at. Zero uses the terminal width,
+	// falling back to zetWordWrap when output is not a terminal.
+	Width int
+	// Raw prints the Markdown source of zets rather than rendering it.
+	Raw bool
+)
+
+// newRenderer returns a terminal renderer configured by Style and Width.
+func newRenderer() (*glamour.TermRenderer, error) {
+	width := Width
+	if width <= 0 {
+		width = zetWordWrap
+		if w, _, ok := term.Size(); ok {
+			width = w
+		}
+	}
+	style := glamour.WithAutoStyle()
+	if Style != "" && Style != "auto" {
+		style = glamour.WithStylePath(Style)
+	}
+	r, err := glamour.NewTermRenderer(style, glamour.WithWordWrap(width))
+	if err != nil {
+		return nil, fmt.Errorf("loading style %q: %w", Style, err)
+	}
+	return r, nil
+}
+
+// renderMarkdown returns content rendered for the terminal, or unchanged when
+// Raw is set.
+func renderMarkdown(content string) (string, error) {
+	if Raw {
+		return content, nil
+	}
+	r, err := newRenderer()
+	if err != nil {
+		return "", err
+	}
+	return r.Render(content)
+}
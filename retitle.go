@@ -0,0 +1,95 @@
+package zet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type RetitleCmd struct {
+	Id    string `arg:"" predictor:"ids" help:"Isosec of the zet to retitle, or 'last'"`
+	Title string `arg:"" help:"New title for the zet"`
+}
+
+func (c *RetitleCmd) Run(ctx context.Context) error {
+	z := new(Zet)
+	zet, err := z.GetZet(c.Id)
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSpace(c.Title)
+	if title == "" {
+		return errors.New("title cannot be empty")
+	}
+	old, updated, err := z.Retitle(zet, title)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Retitled %s %q → %q\n", zet, old, title)
+	for _, u := range updated {
+		fmt.Printf("Updated links in %s\n", u)
+	}
+
+	// The commit spans every zet which linked to the old title.
+	z.Path = filepath.Join(Repo, zet)
+	z.Ids = append([]string{zet}, updated...)
+	z.Op = OpRetitle
+	z.Title = fmt.Sprintf("%s → %s", old, title)
+	err = z.scanAndCommit(ctx, zet)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Retitle rewrites the title of zet and updates any title-bearing links to it
+// found in other zets. It returns the previous title and the ids of the other
+// zets which were modified.
+func (z *Zet) Retitle(zet, title string) (string, []string, error) {
+	err := requirePublic(zet, "retitled")
+	if err != nil {
+		return "", nil, err
+	}
+	p := z.GetReadme(filepath.Join(Repo, zet))
+	c, err := os.ReadFile(p)
+	if err != nil {
+		return "", nil, err
+	}
+	old := parseTitle(string(c))
+	if old == title {
+		return "", nil, fmt.Errorf("%s is already titled %q", zet, title)
+	}
+	err = os.WriteFile(p, []byte(setTitle(string(c), title)), 0664)
+	if err != nil {
+		return "", nil, err
+	}
+
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return "", nil, err
+	}
+	var updated []string
+	for _, f := range files {
+		if f == zet || isPrivate(filepath.Join(Repo, f)) {
+			continue
+		}
+		p := z.GetReadme(filepath.Join(Repo, f))
+		c, err := os.ReadFile(p)
+		if err != nil {
+			return "", nil, err
+		}
+		n := retitleLinks(string(c), zet, old, title)
+		if n == string(c) {
+			continue
+		}
+		err = os.WriteFile(p, []byte(n), 0664)
+		if err != nil {
+			return "", nil, err
+		}
+		updated = append(updated, f)
+	}
+	return old, updated, nil
+}
@@ -0,0 +1,253 @@
+package zet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// zetConfigDir holds zet-cmd state inside the repo so it syncs through git.
+	zetConfigDir = ".zet"
+	reviewFile   = "review.json"
+	// minEase is the lowest ease factor allowed by SM-2.
+	minEase = 1.3
+)
+
+// Schedule is the SM-2 spaced repetition state for a single zet.
+type Schedule struct {
+	Repetitions int     `json:"repetitions"`
+	Interval    int     `json:"interval"`
+	Ease        float64 `json:"ease"`
+	Due         string  `json:"due"`
+}
+
+// Grade updates the schedule with a recall quality between 0 (blackout) and
+// 5 (perfect recall) following the SM-2 algorithm, with today as the day of
+// the review.
+func (s *Schedule) Grade(quality int, today time.Time) {
+	if s.Ease == 0 {
+		s.Ease = 2.5
+	}
+	if quality < 3 {
+		s.Repetitions = 0
+		s.Interval = 1
+	} else {
+		s.Repetitions++
+		switch s.Repetitions {
+		case 1:
+			s.Interval = 1
+		case 2:
+			s.Interval = 6
+		default:
+			s.Interval = int(math.Round(float64(s.Interval) * s.Ease))
+		}
+	}
+	q := float64(5 - quality)
+	s.Ease = math.Max(minEase, s.Ease+0.1-q*(0.08+q*0.02))
+	s.Due = today.AddDate(0, 0, s.Interval).Format(dateLayout)
+}
+
+type RandomCmd struct {
+	Tag string `help:"Only choose from zets with this tag" short:"t" predictor:"tags"`
+}
+
+func (c *RandomCmd) Run() error {
+	z := new(Zet)
+	ids, err := z.candidates(c.Tag)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 && c.Tag != "" {
+		return fmt.Errorf("%w tagged #%s", ErrNoResults, c.Tag)
+	}
+	if len(ids) == 0 {
+		return ErrNoResults
+	}
+	zet := ids[rand.IntN(len(ids))]
+	out, err := renderZet(zet)
+	if err != nil {
+		return err
+	}
+	return page(zet + "\n" + out)
+}
+
+type ReviewCmd struct {
+	Tag   string `help:"Only review zets with this tag" short:"t" predictor:"tags"`
+	Limit int    `help:"Maximum number of zets to review" default:"10" short:"n"`
+}
+
+// Validate is called by kong once the flags are parsed.
+func (c *ReviewCmd) Validate() error {
+	if c.Limit < 1 {
+		return fmt.Errorf("--limit must be at least 1, got %d", c.Limit)
+	}
+	return nil
+}
+
+func (c *ReviewCmd) Run(ctx context.Context) error {
+	z := new(Zet)
+	ids, err := z.candidates(c.Tag)
+	if err != nil {
+		return err
+	}
+	state, err := loadReviews()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	due := dueZets(ids, state, now)
+	if len(due) > c.Limit {
+		due = due[:c.Limit]
+	}
+	if len(due) == 0 {
+		fmt.Println("Nothing due for review")
+		return nil
+	}
+
+	var reviewed int
+session:
+	for i, zet := range due {
+		fmt.Printf(term.U+"%d/%d %s"+term.Reset+"\n", i+1, len(due), zet)
+		// not paged as the rating prompt must follow the zet
+		out, err := renderZet(zet)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		for {
+			p := strings.TrimSpace(term.Prompt("Recall 0-5, (s)kip or (q)uit #> "))
+			if p == "q" || p == "" {
+				break session
+			}
+			if p == "s" {
+				break
+			}
+			q, err := strconv.Atoi(p)
+			if err != nil || q < 0 || q > 5 {
+				fmt.Println("rating must be between 0 and 5")
+				continue
+			}
+			s := state[zet]
+			s.Grade(q, now)
+			state[zet] = s
+			reviewed++
+			fmt.Printf("Next review %s\n", s.Due)
+			break
+		}
+	}
+	if reviewed == 0 {
+		return nil
+	}
+	err = saveReviews(state)
+	if err != nil {
+		return err
+	}
+	z.Path = filepath.Join(Repo, zetConfigDir, reviewFile)
+	z.Op = OpReview
+	z.Title = fmt.Sprintf("%d zets", reviewed)
+	err = z.scanAndCommit(ctx, z.Path)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// candidates returns the ids of every zet, or only those tagged with tag when
+// it is not empty.
+func (z *Zet) candidates(tag string) ([]string, error) {
+	err := z.checkRepo()
+	if err != nil {
+		return nil, err
+	}
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		return files, nil
+	}
+	titles, err := z.FindTags(tag, files)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, t := range titles {
+		ids = append(ids, t.Id)
+	}
+	return ids, nil
+}
+
+// dueZets returns the zets due for review on or before now. Overdue zets come
+// first, most overdue leading, followed by zets never reviewed from the
+// oldest onward as those are the most likely to have been forgotten.
+func dueZets(ids []string, state map[string]Schedule, now time.Time) []string {
+	today := now.Format(dateLayout)
+	var scheduled, unseen []string
+	for _, id := range ids {
+		s, ok := state[id]
+		switch {
+		case !ok:
+			unseen = append(unseen, id)
+		case s.Due <= today:
+			scheduled = append(scheduled, id)
+		}
+	}
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		return state[scheduled[i]].Due < state[scheduled[j]].Due
+	})
+	sort.Strings(unseen)
+	return append(scheduled, unseen...)
+}
+
+// loadReviews reads the review schedule from the repo. A missing file is an
+// empty schedule.
+func loadReviews() (map[string]Schedule, error) {
+	state := make(map[string]Schedule)
+	c, err := os.ReadFile(filepath.Join(Repo, zetConfigDir, reviewFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(c, &state)
+	if err != nil {
+		return nil, fmt.Errorf("invalid review file: %w", err)
+	}
+	return state, nil
+}
+
+// saveReviews writes the review schedule into the repo.
+func saveReviews(state map[string]Schedule) error {
+	err := mkdir(filepath.Join(Repo, zetConfigDir))
+	if err != nil {
+		return err
+	}
+	c, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(Repo, zetConfigDir, reviewFile), append(c, '\n'), 0664)
+}
+
+// renderZet returns the README.md of zet, without its front matter, rendered
+// for the terminal.
+func renderZet(zet string) (string, error) {
+	c, err := readZet(filepath.Join(Repo, zet))
+	if err != nil {
+		return "", err
+	}
+	_, body := splitFrontMatter(c)
+	return renderMarkdown(body)
+}
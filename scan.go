@@ -0,0 +1,74 @@
+package zet
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// scanWorkers bounds the number of goroutines reading zets at once. Reading
+// is mostly waiting on the filesystem so a few more than the CPU count keeps
+// the disk busy without exhausting file descriptors on large repos.
+var scanWorkers = 2 * runtime.GOMAXPROCS(0)
+
+// scan calls fn for every id in files using a bounded pool of goroutines.
+// Results for which fn reports true are returned in the same order as files,
+// regardless of the order in which they complete, so callers passing the
+// output of ReadDir get results in id order. The first error, or the
+// cancellation of ctx, stops any remaining work and is returned.
+func scan[T any](ctx context.Context, files []string, fn func(id string) (T, bool, error)) ([]T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]T, len(files))
+	keep := make([]bool, len(files))
+	var (
+		next    atomic.Int64
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		scanErr error
+	)
+	workers := min(max(scanWorkers, 1), len(files))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(files) {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				r, ok, err := fn(files[i])
+				if err != nil {
+					errOnce.Do(func() {
+						scanErr = err
+						cancel()
+					})
+					return
+				}
+				results[i], keep[i] = r, ok
+			}
+		}()
+	}
+	wg.Wait()
+
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]T, 0, len(files))
+	for i, r := range results {
+		if keep[i] {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
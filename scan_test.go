@@ -0,0 +1,221 @@
+package zet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fixtureSize is the number of zets generated for the scanning benchmarks.
+const fixtureSize = 50000
+
+var (
+	fixtureOnce sync.Once
+	fixtureDir  string
+	fixtureErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if fixtureDir != "" {
+		_ = os.RemoveAll(fixtureDir)
+	}
+	os.Exit(code)
+}
+
+// fixture generates a repo of fixtureSize zets once per test binary and
+// points Repo at it.
+func fixture(b *testing.B) []string {
+	b.Helper()
+	fixtureOnce.Do(func() {
+		fixtureDir, fixtureErr = os.MkdirTemp("", "zet-bench-")
+		if fixtureErr != nil {
+			return
+		}
+		start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < fixtureSize; i++ {
+			dir := filepath.Join(fixtureDir, start.Add(time.Duration(i)*time.Minute).Format("20060102150405"))
+			if fixtureErr = os.Mkdir(dir, 0755); fixtureErr != nil {
+				return
+			}
+			c := fmt.Sprintf("# Zet number %d\n\nSome body text for zet %d.\n\n> #tag%d #bench\n", i, i, i%50)
+			if fixtureErr = os.WriteFile(filepath.Join(dir, "README.md"), []byte(c), 0664); fixtureErr != nil {
+				return
+			}
+		}
+	})
+	if fixtureErr != nil {
+		b.Fatal(fixtureErr)
+	}
+	Repo = fixtureDir
+	z := new(Zet)
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return files
+}
+
+// withWorkers sets scanWorkers to n for the rest of the test.
+func withWorkers(t *testing.T, n int) {
+	defaults := scanWorkers
+	t.Cleanup(func() { scanWorkers = defaults })
+	scanWorkers = n
+}
+
+// numbered returns the ids "0" to "n-1".
+func numbered(n int) []string {
+	files := make([]string, n)
+	for i := range files {
+		files[i] = strconv.Itoa(i)
+	}
+	return files
+}
+
+func TestScanOrder(t *testing.T) {
+	withWorkers(t, 8)
+	files := numbered(500)
+	got, err := scan(context.Background(), files, func(id string) (string, bool, error) {
+		i, _ := strconv.Atoi(id)
+		// later ids finish first so completion order differs from input order
+		time.Sleep(time.Duration(len(files)-i) * time.Microsecond)
+		return id, i%2 == 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for i := 0; i < len(files); i += 2 {
+		want = append(want, files[i])
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestScanError(t *testing.T) {
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	withWorkers(t, 1)
+	var calls atomic.Int64
+	got, err := scan(context.Background(), numbered(10), func(id string) (string, bool, error) {
+		calls.Add(1)
+		switch id {
+		case "3":
+			return "", false, errFirst
+		case "6":
+			return "", false, errSecond
+		}
+		return id, true, nil
+	})
+	if !errors.Is(err, errFirst) || got != nil {
+		t.Errorf("got %v, %v, want the first error and no results", got, err)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("fn called %d times after failing, want scanning to stop", n)
+	}
+
+	withWorkers(t, 4)
+	_, err = scan(context.Background(), numbered(100), func(id string) (string, bool, error) {
+		if id == "50" || id == "51" {
+			return "", false, fmt.Errorf("zet %s: %w", id, errFirst)
+		}
+		return id, true, nil
+	})
+	if !errors.Is(err, errFirst) {
+		t.Errorf("got %v, want one of the errors returned", err)
+	}
+}
+
+func TestScanCancel(t *testing.T) {
+	withWorkers(t, 4)
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	files := numbered(1000)
+	var calls atomic.Int64
+	_, err := scan(ctx, files, func(id string) (string, bool, error) {
+		if calls.Add(1) == 10 {
+			cancel()
+		}
+		return id, true, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if n := calls.Load(); n >= int64(len(files)) {
+		t.Errorf("fn called for all %d files despite cancellation", n)
+	}
+	// scan waits for its workers so none should outlive it
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("%d goroutines running after scan, %d before", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// benchWorkers runs fn as sub-benchmarks with a single worker, which is the
+// equivalent of scanning sequentially, and with the default pool size.
+func benchWorkers(b *testing.B, fn func(b *testing.B)) {
+	defaults := scanWorkers
+	defer func() { scanWorkers = defaults }()
+	for _, w := range []int{1, defaults} {
+		b.Run(fmt.Sprintf("workers=%d", w), func(b *testing.B) {
+			scanWorkers = w
+			fn(b)
+		})
+	}
+}
+
+func BenchmarkFindTitles(b *testing.B) {
+	files := fixture(b)
+	z := new(Zet)
+	benchWorkers(b, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			titles, err := z.FindTitlesContext(context.Background(), files)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(titles) != fixtureSize {
+				b.Fatalf("got %d titles, want %d", len(titles), fixtureSize)
+			}
+		}
+	})
+}
+
+func BenchmarkFindTags(b *testing.B) {
+	files := fixture(b)
+	z := new(Zet)
+	benchWorkers(b, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			titles, err := z.FindTagsContext(context.Background(), "tag7", files)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(titles) == 0 {
+				b.Fatal("no tags found")
+			}
+		}
+	})
+}
+
+func BenchmarkLast(b *testing.B) {
+	fixture(b)
+	z := new(Zet)
+	benchWorkers(b, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := z.LastContext(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
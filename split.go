@@ -0,0 +1,217 @@
+package zet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// section is a level two heading and its content within a README.md. Start
+// and End are line indexes, End being exclusive.
+type section struct {
+	Heading string
+	Start   int
+	End     int
+}
+
+// splitHelp heads the copy of a zet opened by split for marking sections.
+const splitHelp = `<!-- Mark each section to split into a new zet with an x, e.g. "## [x] Heading".
+Only the marks are read, other changes are ignored. Mark none to cancel. -->
+
+`
+
+// markedRegex matches a section heading in the copy opened by split, the
+// group being its mark.
+var markedRegex = regexp.MustCompile(`^## \[([ xX])\] `)
+
+type SplitCmd struct {
+	Id string `arg:"" predictor:"ids" help:"Isosec of the zet to split, or 'last'"`
+}
+
+func (c *SplitCmd) Run(ctx context.Context) error {
+	z := new(Zet)
+	zet, err := z.GetZet(c.Id)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(z.GetReadme(filepath.Join(Repo, zet)))
+	if err != nil {
+		return err
+	}
+	sections := findSections(string(content))
+	if len(sections) == 0 {
+		return fmt.Errorf("%s has no '## ' sections to split", zet)
+	}
+	selected, err := markSections(string(content), sections)
+	if err != nil {
+		return err
+	}
+
+	title := parseTitle(string(content))
+	ids, err := z.Split(zet, selected)
+	if err != nil {
+		return err
+	}
+	for i, id := range ids {
+		fmt.Printf("Created %s %s\n", id, selected[i].Heading)
+	}
+
+	z.Path = filepath.Join(Repo, zet)
+	z.Ids = append([]string{zet}, ids...)
+	z.Op = OpSplit
+	z.Title = title
+	err = z.scanAndCommit(ctx, zet)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Split moves each of the sections out of zet into a new zet of its own which
+// links back to the original. The original keeps the heading along with a
+// link to where the content now lives. Sections must be in document order and
+// the ids of the new zets are returned in the same order.
+func (z *Zet) Split(zet string, sections []section) ([]string, error) {
+	if len(sections) == 0 {
+		return nil, errors.New("no sections selected")
+	}
+	if err := requirePublic(zet, "split"); err != nil {
+		return nil, err
+	}
+	p := z.GetReadme(filepath.Join(Repo, zet))
+	c, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	content := string(c)
+	title := parseTitle(content)
+	tags := formatTags(parseTags(content))
+	lines := strings.Split(content, "\n")
+
+	ids := make([]string, len(sections))
+	for i, s := range sections {
+		dir, err := z.CreateDir()
+		if err != nil {
+			return nil, err
+		}
+		body := strings.TrimSpace(strings.Join(lines[s.Start+1:s.End], "\n"))
+		n := fmt.Sprintf("# %s\n\n%s\n\nSplit from %s\n", s.Heading, body, zetLink(title, zet))
+		if tags != "" {
+			n += "\n" + tags + "\n"
+		}
+		err = os.WriteFile(z.GetReadme(dir), []byte(n), 0664)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = filepath.Base(dir)
+	}
+
+	// replace from the bottom up so earlier line indexes remain valid
+	for i := len(sections) - 1; i >= 0; i-- {
+		s := sections[i]
+		stub := []string{"## " + s.Heading, "", "Moved to " + zetLink(s.Heading, ids[i])}
+		if s.End < len(lines) && strings.TrimSpace(lines[s.End]) != "" {
+			stub = append(stub, "")
+		}
+		lines = append(lines[:s.Start], append(stub, lines[s.End:]...)...)
+	}
+	err = os.WriteFile(p, []byte(strings.Join(lines, "\n")), 0664)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// markSections opens a copy of content in the editor with an empty box before
+// the heading of each of its sections and returns the sections marked. Marking
+// none returns ErrCancelled.
+func markSections(content string, sections []section) ([]section, error) {
+	lines := strings.Split(content, "\n")
+	for _, s := range sections {
+		lines[s.Start] = "## [ ] " + s.Heading
+	}
+	f, err := os.CreateTemp("", "zet-split-*.md")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(splitHelp + strings.Join(lines, "\n"))
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	err = f.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = term.Exec(Editor, f.Name())
+	if err != nil {
+		return nil, err
+	}
+	c, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	var marked []section
+	n, fenced := 0, false
+	for _, l := range strings.Split(string(c), "\n") {
+		if strings.HasPrefix(l, "```") {
+			fenced = !fenced
+		}
+		m := markedRegex.FindStringSubmatch(l)
+		if fenced || m == nil {
+			continue
+		}
+		if n == len(sections) {
+			return nil, fmt.Errorf("%w: sections were added", ErrInvalidSelection)
+		}
+		if m[1] != " " {
+			marked = append(marked, sections[n])
+		}
+		n++
+	}
+	if n != len(sections) {
+		return nil, fmt.Errorf("%w: sections were removed", ErrInvalidSelection)
+	}
+	if len(marked) == 0 {
+		return nil, fmt.Errorf("%w, no sections marked", ErrCancelled)
+	}
+	return marked, nil
+}
+
+// findSections returns the level two sections of content. Headings inside
+// fenced code blocks are ignored and the trailing tags line is never part of
+// the final section.
+func findSections(content string) []section {
+	lines := strings.Split(content, "\n")
+	end := len(lines)
+	for end > 0 && (strings.TrimSpace(lines[end-1]) == "" || isTagLine(lines[end-1])) {
+		end--
+	}
+	var sections []section
+	fenced := false
+	for i := 0; i < end; i++ {
+		l := lines[i]
+		if strings.HasPrefix(l, "```") {
+			fenced = !fenced
+		}
+		if fenced || !strings.HasPrefix(l, "## ") {
+			continue
+		}
+		if n := len(sections); n > 0 {
+			sections[n-1].End = i
+		}
+		sections = append(sections, section{
+			Heading: strings.TrimSpace(strings.TrimPrefix(l, "## ")),
+			Start:   i,
+			End:     end,
+		})
+	}
+	return sections
+}
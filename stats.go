@@ -0,0 +1,213 @@
+package zet
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// topTags is the number of most used tags reported.
+	topTags = 10
+	// heatmapWeeks is the number of weeks shown in the activity heatmap.
+	heatmapWeeks = 53
+)
+
+// heatmapCells shade a day in the heatmap from no zets to four or more.
+var heatmapCells = []string{"·", "░", "▒", "▓", "█"}
+
+// TagCount is the number of zets using a tag.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// Stats is a summary of the zet repository.
+type Stats struct {
+	Total        int            `json:"total"`
+	PerYear      map[string]int `json:"per_year"`
+	PerMonth     map[string]int `json:"per_month"`
+	PerDay       map[string]int `json:"per_day"`
+	TopTags      []TagCount     `json:"top_tags"`
+	AverageWords float64        `json:"average_words"`
+	Links        int            `json:"links"`
+	LinkDensity  float64        `json:"link_density"`
+	Orphans      int            `json:"orphans"`
+}
+
+type StatsCmd struct {
+	Output string `help:"Output format" enum:"text,json" default:"text" short:"o"`
+}
+
+func (c *StatsCmd) Run() error {
+	z := new(Zet)
+	s, err := z.Stats()
+	if err != nil {
+		return err
+	}
+	if c.Output == "json" {
+		out, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	s.print(time.Now())
+	return nil
+}
+
+// Stats gathers statistics over every zet in the repository. Creation dates
+// come from the zet ids rather than the filesystem as those survive a clone.
+// The content of private zets is only included when they can be decrypted.
+func (z *Zet) Stats() (*Stats, error) {
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return nil, err
+	}
+	s := &Stats{
+		Total:    len(files),
+		PerYear:  make(map[string]int),
+		PerMonth: make(map[string]int),
+		PerDay:   make(map[string]int),
+		TopTags:  []TagCount{},
+	}
+	exists := make(map[string]bool, len(files))
+	for _, f := range files {
+		exists[f] = true
+	}
+	tags := make(map[string]int)
+	linked := make(map[string]bool)
+	var words int
+	for _, f := range files {
+		c, err := readReadme(filepath.Join(Repo, f))
+		if isMissing(f, err) {
+			s.Total--
+			continue
+		}
+		if t, ok := IdTime(f); ok {
+			s.PerYear[t.Format("2006")]++
+			s.PerMonth[t.Format("2006-01")]++
+			s.PerDay[t.Format(dateLayout)]++
+		}
+		if isLocked(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, body := splitFrontMatter(c)
+		words += len(strings.Fields(stripTagLines(body)))
+		for _, t := range parseTags(body) {
+			tags[t]++
+		}
+		for _, l := range FindLinks(body) {
+			if l.Id == f || !exists[l.Id] {
+				continue
+			}
+			s.Links++
+			linked[f] = true
+			linked[l.Id] = true
+		}
+	}
+	if s.Total > 0 {
+		s.AverageWords = float64(words) / float64(s.Total)
+		s.LinkDensity = float64(s.Links) / float64(s.Total)
+	}
+	s.Orphans = s.Total - len(linked)
+	for t, n := range tags {
+		s.TopTags = append(s.TopTags, TagCount{Tag: t, Count: n})
+	}
+	sort.Slice(s.TopTags, func(i, j int) bool {
+		if s.TopTags[i].Count == s.TopTags[j].Count {
+			return s.TopTags[i].Tag < s.TopTags[j].Tag
+		}
+		return s.TopTags[i].Count > s.TopTags[j].Count
+	})
+	if len(s.TopTags) > topTags {
+		s.TopTags = s.TopTags[:topTags]
+	}
+	return s, nil
+}
+
+// print writes the statistics to stdout as a human readable report with an
+// activity heatmap ending at now.
+func (s *Stats) print(now time.Time) {
+	fmt.Println(term.U + term.Green + "Zet Statistics" + term.Reset)
+	fmt.Printf(term.Blue+"Total: "+term.Reset+"%d\n", s.Total)
+	fmt.Printf(term.Blue+"Average Length: "+term.Reset+"%.0f words\n", s.AverageWords)
+	fmt.Printf(term.Blue+"Links: "+term.Reset+"%d (%.2f per zet)\n", s.Links, s.LinkDensity)
+	fmt.Printf(term.Blue+"Orphans: "+term.Reset+"%d\n", s.Orphans)
+
+	fmt.Println(term.U + term.Yellow + "Per Year" + term.Reset)
+	for _, k := range sortedKeys(s.PerYear) {
+		fmt.Printf(term.Blue+"%s: "+term.Reset+"%d\n", k, s.PerYear[k])
+	}
+	fmt.Println(term.U + term.Yellow + "Per Month" + term.Reset)
+	for _, k := range sortedKeys(s.PerMonth) {
+		fmt.Printf(term.Blue+"%s: "+term.Reset+"%d\n", k, s.PerMonth[k])
+	}
+	fmt.Println(term.U + term.Yellow + "Top Tags" + term.Reset)
+	for _, t := range s.TopTags {
+		fmt.Printf(term.Blue+"#%s: "+term.Reset+"%d\n", t.Tag, t.Count)
+	}
+	fmt.Println(term.U + term.Yellow + "Activity" + term.Reset)
+	fmt.Print(s.heatmap(now))
+}
+
+// heatmap returns a grid of zets created per day over the past year in the
+// style of a GitHub contribution graph. Each column is a week starting on
+// Sunday and the final column holds the week of now.
+func (s *Stats) heatmap(now time.Time) string {
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := end.AddDate(0, 0, -int(end.Weekday())-7*(heatmapWeeks-1))
+
+	var b strings.Builder
+	// month labels above the first week of each month
+	b.WriteString("    ")
+	for w := 0; w < heatmapWeeks; w++ {
+		d := start.AddDate(0, 0, 7*w)
+		if d.Day() <= 7 {
+			b.WriteString(d.Format("Jan")[:1])
+			continue
+		}
+		b.WriteString(" ")
+	}
+	b.WriteString("\n")
+	for day := 0; day < 7; day++ {
+		label := "   "
+		if day%2 == 1 {
+			label = time.Weekday(day).String()[:3]
+		}
+		b.WriteString(label + " ")
+		for w := 0; w < heatmapWeeks; w++ {
+			d := start.AddDate(0, 0, 7*w+day)
+			if d.After(end) {
+				break
+			}
+			n := min(s.PerDay[d.Format(dateLayout)], len(heatmapCells)-1)
+			if n == 0 {
+				b.WriteString(term.Dim + heatmapCells[0] + term.Reset)
+				continue
+			}
+			b.WriteString(term.Green + heatmapCells[n] + term.Reset)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
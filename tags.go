@@ -0,0 +1,105 @@
+package zet
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// tagRegex matches a single hashtag such as #golang or #zet-cmd.
+var tagRegex = regexp.MustCompile(`#([\p{L}\p{N}_-]+)`)
+
+// isTagLine reports whether line is a zet tags line, by convention a
+// blockquote of hashtags at the end of the README.md e.g. `> #tag1 #tag2`.
+func isTagLine(line string) bool {
+	l := strings.TrimSpace(line)
+	if !strings.HasPrefix(l, ">") {
+		return false
+	}
+	return strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(l, ">")), "#")
+}
+
+// parseTags returns the unique tags, without the leading '#', found on the
+// tags lines of content in the order they first appear.
+func parseTags(content string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, l := range strings.Split(content, "\n") {
+		if !isTagLine(l) {
+			continue
+		}
+		for _, m := range tagRegex.FindAllStringSubmatch(l, -1) {
+			if seen[m[1]] {
+				continue
+			}
+			seen[m[1]] = true
+			tags = append(tags, m[1])
+		}
+	}
+	return tags
+}
+
+// hasTag reports whether content is tagged with exactly tag, which may be
+// given with or without the leading '#'.
+func hasTag(content, tag string) bool {
+	return slices.Contains(parseTags(content), strings.TrimPrefix(tag, "#"))
+}
+
+// formatTags returns tags as a zet tags line.
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "> #" + strings.Join(tags, " #")
+}
+
+// stripTagLines returns content without any tags lines and with trailing
+// blank lines removed.
+func stripTagLines(content string) string {
+	var out []string
+	for _, l := range strings.Split(content, "\n") {
+		if isTagLine(l) {
+			continue
+		}
+		out = append(out, l)
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// AllTags returns every tag used in the zettelkasten in name order. Zets are
+// read concurrently and private zets are skipped unless they can be
+// decrypted.
+func (z *Zet) AllTags(ctx context.Context) ([]string, error) {
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return nil, err
+	}
+	found, err := scan(ctx, files, func(id string) ([]string, bool, error) {
+		c, err := readReadme(filepath.Join(Repo, id))
+		if isLocked(err) || isMissing(id, err) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return parseTags(c), true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	seen := make(map[string]bool)
+	for _, ts := range found {
+		for _, t := range ts {
+			if !seen[t] {
+				seen[t] = true
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
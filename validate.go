@@ -0,0 +1,143 @@
+package zet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/zet-cmd/internal/term"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// secretPatterns match common credentials which should never be committed,
+// keyed by the name reported when one is found.
+var secretPatterns = []struct {
+	Name  string
+	Regex *regexp.Regexp
+}{
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"Stripe key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{24,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`)},
+}
+
+// ValidationError lists the problems which stopped a zet being committed.
+type ValidationError struct {
+	Id       string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("zet %s is not ready to commit:\n  %s", e.Id, strings.Join(e.Problems, "\n  "))
+}
+
+// Validate returns the problems with content, the README.md of a zet, which
+// should stop it being committed: a missing title or tags line, links to zets
+// which do not exist and anything resembling a secret. Wiki links must name
+// the title or id of one of titles. Journals are exempt from needing tags.
+func Validate(content string, titles []Title) []string {
+	var problems []string
+	if parseTitle(content) == "" {
+		problems = append(problems, "missing a title")
+	}
+	tagged := false
+	for _, l := range strings.Split(content, "\n") {
+		if isTagLine(l) {
+			tagged = true
+			break
+		}
+	}
+	if !tagged && !isJournal(content) {
+		problems = append(problems, "missing a tags line, e.g. > #tag1 #tag2")
+	}
+	for _, m := range wikiLinkRegex.FindAllStringSubmatch(content, -1) {
+		if !resolves(m[1], titles) {
+			problems = append(problems, fmt.Sprintf("unresolved link [[%s]]", m[1]))
+		}
+	}
+	for _, l := range FindLinks(content) {
+		if _, err := os.Stat(filepath.Join(Repo, l.Id)); errors.Is(err, os.ErrNotExist) {
+			problems = append(problems, fmt.Sprintf("link to missing zet %s", l.Id))
+		}
+	}
+	for _, s := range secretPatterns {
+		if s.Regex.MatchString(content) {
+			problems = append(problems, "possible "+s.Name)
+		}
+	}
+	return problems
+}
+
+// resolves reports whether the target of a wiki link, ignoring any heading or
+// alias, is the title or id of one of titles.
+func resolves(target string, titles []Title) bool {
+	target, _, _ = strings.Cut(target, "|")
+	target, _, _ = strings.Cut(target, "#")
+	target = strings.TrimSpace(target)
+	for _, t := range titles {
+		if t.Id == target || strings.EqualFold(t.Title, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkTitles returns the titles of every zet for resolving the wiki links in
+// content, scanning the repo only when it has any.
+func (z *Zet) linkTitles(ctx context.Context, content string) ([]Title, error) {
+	if !wikiLinkRegex.MatchString(content) {
+		return nil, nil
+	}
+	files, err := z.ReadDir(Repo)
+	if err != nil {
+		return nil, err
+	}
+	return z.FindTitlesContext(ctx, files)
+}
+
+// isJournal reports whether content is a journal, see Journals.
+func isJournal(content string) bool {
+	fm, _ := splitFrontMatter(content)
+	return fm[journalKey] != "" || journalTitleRegex.MatchString(parseTitle(content))
+}
+
+// validateZet returns a ValidationError when the zet at path fails Validate.
+func (z *Zet) validateZet(ctx context.Context, path string) error {
+	c, err := readZet(path)
+	if err != nil {
+		return err
+	}
+	titles, err := z.linkTitles(ctx, c)
+	if err != nil {
+		return err
+	}
+	if p := Validate(c, titles); len(p) > 0 {
+		return &ValidationError{Id: filepath.Base(path), Problems: p}
+	}
+	return nil
+}
+
+// checkZet validates the zet at path, offering to reopen it in the editor
+// until it passes or the user gives up. Given up zets are left uncommitted.
+func (z *Zet) checkZet(ctx context.Context, path string) error {
+	for {
+		err := z.validateZet(ctx, path)
+		var v *ValidationError
+		if !errors.As(err, &v) {
+			return err
+		}
+		fmt.Println(v)
+		if term.Prompt("Edit again? (y/N) ") != "y" {
+			return fmt.Errorf("%s not committed, fix it and run zet commit", v.Id)
+		}
+		err = z.openZetForEdit(path)
+		if err != nil {
+			return err
+		}
+	}
+}
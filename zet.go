@@ -4,21 +4,23 @@
 package zet
 
 import (
-	"bufio"
+	"context"
 	"errors"
 	"fmt"
-	"github.com/charmbracelet/glamour"
 	"github.com/danielmichaels/zet-cmd/internal/term"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
 
 const (
-	zetRegex    = "^[0-9]{14,}$"
+	zetRegex    = "^(?:" + idPattern + ")$"
 	zetWordWrap = 73
+	dateLayout  = "2006-01-02"
 )
 
 var (
@@ -26,10 +28,11 @@ var (
 	Editor      = os.Getenv("EDITOR")
 	GitUser     = os.Getenv("GITUSER")
 	RepoName    = "zet"
-	Repo        = filepath.Join(os.Getenv("ZETDIR"))
+	Repo        = absPath(os.Getenv("ZETDIR"))
 	Pictures    = filepath.Join(os.Getenv("HOME"), "Pictures", "zet")
 	Screenshots = filepath.Join(os.Getenv("HOME"), "Pictures", "zet")
 	Downloads   = filepath.Join(os.Getenv("HOME"), "Downloads")
+	IdScheme    = os.Getenv("ZET_ID_SCHEME")
 )
 
 // Zet is the struct to hang methods from which are used to create, edit, find
@@ -38,6 +41,12 @@ type Zet struct {
 	Title  string
 	Path   string
 	Latest string
+	// Op and Id describe the change being committed, see commitMessage.
+	// Ids lists every zet committed when a change spans several, in which
+	// case only those zets are staged.
+	Op  string
+	Id  string
+	Ids []string
 }
 
 func (z *Zet) render(arg string) error {
@@ -45,51 +54,42 @@ func (z *Zet) render(arg string) error {
 	if err != nil {
 		return err
 	}
-	p := z.GetReadme(filepath.Join(Repo, z.Path))
-	r, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(), glamour.WithWordWrap(zetWordWrap),
-	)
-	if err != nil {
-		return err
-	}
-	c, err := os.ReadFile(p)
+	c, err := readZet(z.Path)
 	if err != nil {
 		return err
 	}
 
-	out, err := r.Render(string(c))
+	out, err := renderMarkdown(c)
 	if err != nil {
 		return err
 	}
-	fmt.Print(out)
-	return nil
+	return page(out)
 }
 
 // FindTags takes a tag and array of files and then searches the files for the
 // tag. Any matches are returned as a slice of Title structs containing the
 // Id and Title of the file with the match.
 func (z *Zet) FindTags(tag string, files []string) ([]Title, error) {
-	var titles []Title
-	for _, t := range files {
-		z.Path = t
-		tag, err := z.SearchTags(tag)
-		if err != nil {
-			return nil, err
-		}
-		if !tag {
-			continue
+	return z.FindTagsContext(context.Background(), tag, files)
+}
+
+// FindTagsContext is FindTags with the files read concurrently. Results are
+// in the same order as files and scanning stops if ctx is cancelled. Private
+// zets are only searched when a passphrase is available.
+func (z *Zet) FindTagsContext(ctx context.Context, tag string, files []string) ([]Title, error) {
+	return scan(ctx, files, func(id string) (Title, bool, error) {
+		c, err := readReadme(filepath.Join(Repo, id))
+		if isLocked(err) || isMissing(id, err) {
+			return Title{}, false, nil
 		}
-		err = z.GetTitle()
 		if err != nil {
-			return nil, err
+			return Title{}, false, err
 		}
-		title := Title{
-			Id:    t,
-			Title: z.Title,
+		if !hasTag(c, tag) {
+			return Title{}, false, nil
 		}
-		titles = append(titles, title)
-	}
-	return titles, nil
+		return Title{Id: id, Title: parseTitle(c)}, true, nil
+	})
 }
 
 // Title holds the id and title for a given Zet when searching the filesystem
@@ -101,20 +101,36 @@ type Title struct {
 // FindTitles searches through a slice of files inspecting the title (in Zet
 // parlance this is the first line of a Readme) and returns a slice of Title
 func (z *Zet) FindTitles(files []string) ([]Title, error) {
-	var titles []Title
-	for _, t := range files {
-		z.Path = t
-		err := z.GetTitle()
-		if err != nil {
-			return nil, err
+	return z.FindTitlesContext(context.Background(), files)
+}
+
+// FindTitlesContext is FindTitles with the files read concurrently. Results
+// are in the same order as files and scanning stops if ctx is cancelled.
+// Private zets are listed as locked when they cannot be decrypted.
+func (z *Zet) FindTitlesContext(ctx context.Context, files []string) ([]Title, error) {
+	return scan(ctx, files, func(id string) (Title, bool, error) {
+		c, err := readReadme(filepath.Join(Repo, id))
+		if isLocked(err) {
+			return Title{Id: id, Title: lockedTitle}, true, nil
+		}
+		if isMissing(id, err) {
+			return Title{}, false, nil
 		}
-		title := Title{
-			Id:    t,
-			Title: z.Title,
+		if err != nil {
+			return Title{}, false, err
 		}
-		titles = append(titles, title)
+		return Title{Id: id, Title: parseTitle(c)}, true, nil
+	})
+}
+
+// isMissing reports whether err is because the zet with id has no README.md,
+// such as one left half created, logging a warning as it is skipped.
+func isMissing(id string, err error) bool {
+	if !errors.Is(err, os.ErrNotExist) {
+		return false
 	}
-	return titles, nil
+	slog.Warn("skipping zet without a README.md", "id", id)
+	return true
 }
 
 // SearchTitles searches through a slice of Title for any matching query element.
@@ -140,60 +156,34 @@ func (z *Zet) GetRepo() string { return Repo }
 // is used to retrieve the full path to the README.md being written to or read from.
 func (z *Zet) GetReadme(path string) string { return filepath.Join(path, "README.md") }
 
-// SearchTags scans an open file for a tag and uses regexp to find any matches.
-// Matching tags return a truthy boolean.
+// SearchTags reads the Zet README.md from z.Path and reports whether it is
+// tagged with tag.
 func (z *Zet) SearchTags(tag string) (bool, error) {
-	reg := regexp.MustCompile(fmt.Sprintf("(#%s+)", tag))
-	f, _ := os.Open(z.GetReadme(z.Path))
-	defer func(f *os.File) {
-		_ = f.Close()
-	}(f)
-	s := bufio.NewScanner(f)
-	hit := false
-	for s.Scan() {
-		match := reg.MatchString(s.Text())
-		if match {
-			hit = true
-		}
-	}
-	if err := s.Err(); err != nil {
+	c, err := readReadme(z.Path)
+	if err != nil {
 		return false, err
 	}
-	return hit, nil
+	return hasTag(c, tag), nil
 }
 
 // GetTitle inspects the Zet README.md from the z.Path and retrieves the
-// h1 title. This ensures that the title is up-to-date as it may have been
-// altered after its initial creation.
+// h1 title, or the front matter title if one is set. This ensures that the
+// title is up-to-date as it may have been altered after its initial creation.
 func (z *Zet) GetTitle() error {
-	f, _ := os.Open(z.GetReadme(z.Path))
-	defer func(f *os.File) {
-		_ = f.Close()
-	}(f)
-	s := bufio.NewScanner(f)
-	var line int
-	for s.Scan() {
-		// only get first line and skip everything else
-		if line >= 1 {
-			break
-		}
-		t := strings.Replace(s.Text(), "#", "", -1)
-		t = strings.TrimSpace(t)
-		z.Title = t
-		line++
-	}
-	if err := s.Err(); err != nil {
+	c, err := readReadme(z.Path)
+	if err != nil {
 		return err
 	}
+	z.Title = parseTitle(c)
 	return nil
 }
 
 func (z *Zet) GetZet(zet string) (string, error) {
-	r := regexp.MustCompile("^[0-9]{14,}$")
+	r := regexp.MustCompile(zetRegex)
 	l := regexp.MustCompile("last")
 	switch {
 	case l.MatchString(zet):
-		err := z.ChangeDir(z.GetRepo())
+		err := z.checkRepo()
 		if err != nil {
 			return "", err
 		}
@@ -203,7 +193,7 @@ func (z *Zet) GetZet(zet string) (string, error) {
 		}
 		return l, nil
 	case r.MatchString(zet):
-		err := z.ChangeDir(z.GetRepo())
+		err := z.checkRepo()
 		if err != nil {
 			return "", err
 		}
@@ -225,7 +215,9 @@ func (z *Zet) CreateReadme(r Zet, path string) error {
 	return nil
 }
 
-// ReadDir reads all files within a given path excluding the .git repo
+// ReadDir reads all files within a given path excluding the .git repo. The
+// zet ids are returned in order of creation, oldest first, so that repos
+// mixing id schemes still list chronologically.
 func (z *Zet) ReadDir(path string) ([]string, error) {
 	r := regexp.MustCompile(zetRegex)
 	var files []string
@@ -240,26 +232,42 @@ func (z *Zet) ReadDir(path string) ([]string, error) {
 		}
 		files = append(files, f.Name())
 	}
+	sort.SliceStable(files, func(i, j int) bool {
+		ti, _ := IdTime(files[i])
+		tj, _ := IdTime(files[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return files[i] < files[j]
+	})
 	return files, nil
 }
 
-// CreateDir creates a directory inside the zet repository using the Isosec
-// function to create the directory using the returned timestamp.
+// CreateDir creates a directory inside the zet repository named with a new id
+// for the current time. See CreateDirAt for how collisions are handled.
 func (z *Zet) CreateDir() (string, error) {
-	path := filepath.Join(Repo, Isosec())
-	err := mkdir(path)
-	if err != nil {
-		return "", err
+	return z.CreateDirAt(time.Now())
+}
+
+// absPath returns p made absolute so it does not depend on the working
+// directory. An empty p is left empty rather than becoming the cwd.
+func absPath(p string) string {
+	if p == "" {
+		return p
+	}
+	if a, err := filepath.Abs(p); err == nil {
+		return a
 	}
-	return path, nil
+	return filepath.Clean(p)
 }
 
-// ChangeDir must be called during any git operation otherwise the git command
-// cannot be called within the zet repo reliably
-func (z *Zet) ChangeDir(path string) error {
-	err := os.Chdir(path)
-	if err != nil {
-		return fmt.Errorf("file does not exist %q", path)
+// checkRepo returns an error when the zet repo does not exist. Commands check
+// it up front rather than failing part way through. The working directory is
+// never changed, every path is joined to Repo and git is run from it.
+func (z *Zet) checkRepo() error {
+	fi, err := os.Stat(Repo)
+	if err != nil || !fi.IsDir() {
+		return fmt.Errorf("file does not exist %q", Repo)
 	}
 	return nil
 }
@@ -267,22 +275,36 @@ func (z *Zet) ChangeDir(path string) error {
 // Last inspects the Zet repo directories (Isosec folders) and returns the
 // most recent directory.
 func (z *Zet) Last() (string, error) {
-	files, _ := os.ReadDir(z.GetRepo())
-	r := regexp.MustCompile(zetRegex)
-	var last string
-	var newest int64 = 0
-	for _, f := range files {
-		if !r.MatchString(f.Name()) {
-			continue
-		}
-		fi, err := os.Stat(f.Name())
+	return z.LastContext(context.Background())
+}
+
+// LastContext is Last with the directories inspected concurrently. When two
+// directories share a modification time the lower id wins.
+func (z *Zet) LastContext(ctx context.Context) (string, error) {
+	files, err := z.ReadDir(z.GetRepo())
+	if err != nil {
+		return "", err
+	}
+	type modTime struct {
+		id   string
+		unix int64
+	}
+	times, err := scan(ctx, files, func(id string) (modTime, bool, error) {
+		fi, err := os.Stat(filepath.Join(Repo, id))
 		if err != nil {
-			return "", err
+			return modTime{}, false, err
 		}
-		currTime := fi.ModTime().Unix()
-		if currTime > newest {
-			newest = currTime
-			last = f.Name()
+		return modTime{id: id, unix: fi.ModTime().Unix()}, true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	var last string
+	var newest int64 = 0
+	for _, t := range times {
+		if t.unix > newest {
+			newest = t.unix
+			last = t.id
 		}
 	}
 	// Set path on Zet now as its used everywhere
@@ -294,11 +316,15 @@ func (z *Zet) Last() (string, error) {
 // configuration such as environment variables and directory paths. This is
 // useful for debugging issues with the host system or failures for the exe
 // to commit to GitHub successfully.
-func (z *Zet) CheckZetConfig() error {
+func (z *Zet) CheckZetConfig(ctx context.Context) error {
 	fmt.Println(term.U + term.Green + "Checking Zet Config" + term.Reset)
 	// System variables
 	fmt.Println(term.Blue + "Editor: " + term.Reset + Editor)
-	fmt.Println(term.Blue + "Pager: " + term.Reset + Pager)
+	pager := Pager
+	if pager == "" {
+		pager = defaultPager + " (default)"
+	}
+	fmt.Println(term.Blue + "Pager: " + term.Reset + pager)
 	// Git/Repo variables
 	fmt.Println(term.U + term.Yellow + "Repository Variables" + term.Reset)
 	fmt.Println(term.Blue + "RepoName: " + term.Reset + RepoName)
@@ -310,6 +336,16 @@ func (z *Zet) CheckZetConfig() error {
 	fmt.Println(term.Blue + "GitUser: " + term.Reset + GitUser)
 	fmt.Println(term.Blue + "Repo: " + term.Reset + Repo)
 	fmt.Println(term.Blue + "System Zet Repo: " + term.Reset + z.GetRepo())
+	scheme := IdScheme
+	if scheme == "" {
+		scheme = SchemeIsosec
+	}
+	fmt.Println(term.Blue + "Id Scheme: " + term.Reset + scheme)
+	passphrase := "true"
+	if Passphrase == "" {
+		passphrase = term.Yellow + "false (private zets will prompt)" + term.Reset
+	}
+	fmt.Println(term.Blue + "Private Zet Passphrase Set: " + term.Reset + passphrase)
 	// Future use case info
 	fmt.Println(term.U + term.Yellow + "Utility Directories" + term.Reset)
 	fmt.Println(term.Blue + "Pictures Directory: " + term.Reset + Pictures)
@@ -327,11 +363,7 @@ func (z *Zet) CheckZetConfig() error {
 		fmt.Println(term.Blue + "Zet GitHub Remote: " + term.Red + "Zet repo does not exist on host" + term.Reset)
 		return nil
 	}
-	err = z.ChangeDir(z.GetRepo())
-	if err != nil {
-		return err
-	}
-	err = z.GitRemote()
+	err = z.GitRemote(ctx)
 	zetRemote := "true"
 	if err != nil {
 		zetRemote = term.Red + "false" + term.Reset
@@ -348,6 +380,22 @@ func Isosec() string {
 	return fmt.Sprintf("%v", time.Now().In(time.UTC).Format("20060102150405"))
 }
 
+// IdTime returns the creation time encoded in a zet id. Ids which do not
+// carry a timestamp report false.
+func IdTime(id string) (time.Time, bool) {
+	if t, ok := ulidTime(id); ok {
+		return t, true
+	}
+	if len(id) < 14 {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("20060102150405", id[:14], time.UTC)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
 // mkdir is the functional equivalent of 'mkdir -p' and is used to create new
 // folders recursively.
 func mkdir(path string) error {